@@ -10,16 +10,29 @@
 
 package errors
 
+import "net/http"
+
+var (
+	ConnNotFount      = New("conn not found")
+	ClientClosed      = New("client closed")
+	NilError          = New("nil error")
+	ServerClosed      = New("server closed")
+	AssertionFailed   = New("assertion failed")
+	StopPropagation   = New("stop propagation")
+	RouteConflict     = New("route conflict")
+	RouteNameNotFound = New("route name not found")
+	MissingParam      = New("missing param")
+)
+
+// errors a client may be told about, the code mirrors the http status
 var (
-	ConnNotFount     = New("conn not found")
-	RouteNotFount    = New("route not found")
-	MethodNotAllowed = New("method not allowed")
-	ClientClosed     = New("client closed")
-	NilError         = New("nil error")
-	ServerClosed     = New("server closed")
-	Timeout          = New("timeout")
-	Invalid          = New("invalid")
-	MaximumExceeded  = New("maximum exceeded")
-	AssertionFailed  = New("assertion failed")
-	StopPropagation  = New("stop propagation")
+	Invalid            = Register(400, http.StatusBadRequest, "invalid")
+	Unauthorized       = Register(401, http.StatusUnauthorized, "unauthorized")
+	Forbidden          = Register(403, http.StatusForbidden, "forbidden")
+	RouteNotFount      = Register(404, http.StatusNotFound, "route not found")
+	MethodNotAllowed   = Register(405, http.StatusMethodNotAllowed, "method not allowed")
+	Timeout            = Register(408, http.StatusRequestTimeout, "timeout")
+	MaximumExceeded    = Register(413, http.StatusRequestEntityTooLarge, "maximum exceeded")
+	RateLimited        = Register(429, http.StatusTooManyRequests, "rate limited")
+	TooManyConnections = Register(503, http.StatusServiceUnavailable, "too many connections")
 )
@@ -0,0 +1,114 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 22:40
+**/
+
+package errors
+
+import (
+	"strconv"
+	"sync"
+)
+
+var registry = struct {
+	mux   sync.RWMutex
+	codes map[uint32]*CodeError
+}{codes: make(map[uint32]*CodeError)}
+
+// Register declares an error with a socket code, an http status and a message
+// safe to show to clients. Codes are unique, registering one twice panics.
+func Register(code uint32, status int, message string) *CodeError {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+
+	if _, ok := registry.codes[code]; ok {
+		panic("errors: code " + strconv.FormatUint(uint64(code), 10) + " is already registered")
+	}
+
+	var e = &CodeError{code: code, status: status, message: message}
+	registry.codes[code] = e
+	return e
+}
+
+// Lookup returns the error registered with code, or nil.
+func Lookup(code uint32) *CodeError {
+	registry.mux.RLock()
+	defer registry.mux.RUnlock()
+	return registry.codes[code]
+}
+
+// CodeError is a registered error. Copies made by WithCause keep
+// the code and still match the registered one with Is.
+type CodeError struct {
+	code    uint32
+	status  int
+	message string
+	cause   error
+}
+
+func (e *CodeError) Code() uint32 {
+	return e.code
+}
+
+func (e *CodeError) Status() int {
+	return e.status
+}
+
+// Message is the public message, the cause is left out.
+func (e *CodeError) Message() string {
+	return e.message
+}
+
+func (e *CodeError) Cause() error {
+	return e.cause
+}
+
+func (e *CodeError) Error() string {
+	if e.cause == nil {
+		return e.message
+	}
+	return e.message + ": " + e.cause.Error()
+}
+
+func (e *CodeError) Unwrap() error {
+	return e.cause
+}
+
+func (e *CodeError) Is(target error) bool {
+	t, ok := target.(*CodeError)
+	return ok && t.code == e.code
+}
+
+// WithCause returns a copy of e carrying the internal cause.
+func (e *CodeError) WithCause(cause error) error {
+	return &CodeError{code: e.code, status: e.status, message: e.message, cause: cause}
+}
+
+// StatusOf returns the http status declared by the outermost error
+// in the chain of err that has a Status() int method.
+func StatusOf(err error) (int, bool) {
+	var status int
+	var ok = walk(err, func(err error) bool {
+		if s, is := err.(interface{ Status() int }); is {
+			status = s.Status()
+			return true
+		}
+		return false
+	})
+	return status, ok
+}
+
+// MessageOf returns the public message of the outermost CodeError
+// in the chain of err, or err.Error() when there is none.
+func MessageOf(err error) string {
+	var message = err.Error()
+	walk(err, func(err error) bool {
+		if e, is := err.(*CodeError); is {
+			message = e.message
+			return true
+		}
+		return false
+	})
+	return message
+}
@@ -12,11 +12,13 @@ package errors
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/lemonyxk/kitty/kitty"
 	"io"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -31,9 +33,10 @@ func WithStack(b bool) {
 }
 
 type Error struct {
-	errs  []error
-	stack []caller.Info
-	buf   *bytes.Buffer
+	errs   []error
+	stack  []caller.Info
+	buf    *bytes.Buffer
+	joined bool
 }
 
 func (e *Error) Error() string {
@@ -41,6 +44,16 @@ func (e *Error) Error() string {
 		return ""
 	}
 
+	if e.buf == nil && e.joined {
+		e.buf = new(bytes.Buffer)
+		for i := 0; i < len(e.errs); i++ {
+			if i != 0 {
+				_, _ = io.WriteString(e.buf, "\n")
+			}
+			_, _ = io.WriteString(e.buf, e.errs[i].Error())
+		}
+	}
+
 	if e.buf == nil {
 		e.buf = new(bytes.Buffer)
 		for i := len(e.errs) - 1; i >= 0; i-- {
@@ -83,6 +96,40 @@ func (e *Error) Format(s fmt.State, verb rune) {
 	}
 }
 
+type jsonFrame struct {
+	Func string `json:"func"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+type jsonError struct {
+	Message string      `json:"message"`
+	Chain   []string    `json:"chain"`
+	Stack   []jsonFrame `json:"stack,omitempty"`
+}
+
+// MarshalJSON renders the message, the chain from outermost to innermost
+// and the stack frames, for log pipelines.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	var res = jsonError{Message: e.Error(), Chain: make([]string, 0, len(e.errs))}
+
+	if e.joined {
+		for i := 0; i < len(e.errs); i++ {
+			res.Chain = append(res.Chain, e.errs[i].Error())
+		}
+	} else {
+		for i := len(e.errs) - 1; i >= 0; i-- {
+			res.Chain = append(res.Chain, e.errs[i].Error())
+		}
+	}
+
+	for i := 0; i < len(e.stack); i++ {
+		res.Stack = append(res.Stack, jsonFrame{Func: e.stack[i].Func, File: e.stack[i].File, Line: e.stack[i].Line})
+	}
+
+	return json.Marshal(res)
+}
+
 func (e *Error) Unwrap() error {
 	if len(e.errs) == 0 {
 		return nil
@@ -194,7 +241,7 @@ func Is(err, target error) bool {
 		if e1, ok1 := target.(*Error); ok1 {
 			for i := 0; i < len(e.errs); i++ {
 				for j := 0; j < len(e1.errs); j++ {
-					if errors.Is(e.errs[i], e1.errs[j]) {
+					if is(e.errs[i], e1.errs[j]) {
 						return true
 					}
 				}
@@ -202,7 +249,7 @@ func Is(err, target error) bool {
 			return false
 		}
 		for i := 0; i < len(e.errs); i++ {
-			if errors.Is(e.errs[i], target) {
+			if is(e.errs[i], target) {
 				return true
 			}
 		}
@@ -210,14 +257,88 @@ func Is(err, target error) bool {
 	} else {
 		if e1, ok1 := target.(*Error); ok1 {
 			for i := 0; i < len(e1.errs); i++ {
-				if errors.Is(err, e1.errs[i]) {
+				if is(err, e1.errs[i]) {
 					return true
 				}
 			}
 			return false
 		}
-		return errors.Is(err, target)
+		return is(err, target)
+	}
+}
+
+// is works like errors.Is but never calls Unwrap on *Error, which pops it.
+func is(err, target error) bool {
+	if target == nil {
+		return err == nil
+	}
+	var comparable = reflect.TypeOf(target).Comparable()
+	return walk(err, func(err error) bool {
+		if comparable && err == target {
+			return true
+		}
+		if e, ok := err.(interface{ Is(error) bool }); ok && e.Is(target) {
+			return true
+		}
+		return false
+	})
+}
+
+// As finds the outermost error in the chain of err that matches target,
+// a non nil pointer to an interface or to a type implementing error.
+// Unlike errors.As it leaves *Error untouched.
+func As(err error, target any) bool {
+	if target == nil {
+		panic("errors: target cannot be nil")
+	}
+
+	var val = reflect.ValueOf(target)
+	var typ = val.Type()
+	if typ.Kind() != reflect.Ptr || val.IsNil() {
+		panic("errors: target must be a non-nil pointer")
+	}
+
+	var targetType = typ.Elem()
+	if targetType.Kind() != reflect.Interface && !targetType.Implements(reflect.TypeOf((*error)(nil)).Elem()) {
+		panic("errors: *target must be interface or implement error")
+	}
+
+	return walk(err, func(err error) bool {
+		if reflect.TypeOf(err).AssignableTo(targetType) {
+			val.Elem().Set(reflect.ValueOf(err))
+			return true
+		}
+		if e, ok := err.(interface{ As(any) bool }); ok && e.As(target) {
+			return true
+		}
+		return false
+	})
+}
+
+// Join returns an error wrapping every non nil err, Is and As look into
+// each of them. The stack of the first *Error is kept, or taken here.
+func Join(errs ...error) error {
+	var r = &Error{joined: true}
+
+	for i := 0; i < len(errs); i++ {
+		if errs[i] == nil {
+			continue
+		}
+		r.errs = append(r.errs, errs[i])
+		if e, ok := errs[i].(*Error); ok && r.stack == nil {
+			r.stack = e.stack
+		}
+	}
+
+	if len(r.errs) == 0 {
+		return nil
 	}
+
+	if r.stack == nil && withStack {
+		r.stack = caller.Deeps(2)
+	}
+
+	return r
 }
 
 func Unwrap(err error) error {
@@ -226,3 +347,64 @@ func Unwrap(err error) error {
 	}
 	return errors.Unwrap(err)
 }
+
+// CodeOf returns the code declared by the outermost error in the chain
+// of err that has a Code() uint32 method.
+func CodeOf(err error) (uint32, bool) {
+	var code uint32
+	var ok = walk(err, func(err error) bool {
+		if c, is := err.(interface{ Code() uint32 }); is {
+			code = c.Code()
+			return true
+		}
+		return false
+	})
+	return code, ok
+}
+
+// Walk visits err and everything it wraps, outermost first,
+// until fn returns true. It is safe to use on *Error.
+func Walk(err error, fn func(err error) bool) bool {
+	return walk(err, fn)
+}
+
+// walk visits err and everything it wraps, outermost first,
+// until fn returns true. Unlike Unwrap it leaves *Error untouched.
+// Joined errors are visited in the order they were joined.
+func walk(err error, fn func(err error) bool) bool {
+	for err != nil {
+		if fn(err) {
+			return true
+		}
+
+		switch e := err.(type) {
+		case *Error:
+			if e.joined {
+				for i := 0; i < len(e.errs); i++ {
+					if walk(e.errs[i], fn) {
+						return true
+					}
+				}
+				return false
+			}
+			// Wrap appends the outer text last
+			for i := len(e.errs) - 1; i >= 0; i-- {
+				if walk(e.errs[i], fn) {
+					return true
+				}
+			}
+			return false
+		case interface{ Unwrap() []error }:
+			var list = e.Unwrap()
+			for i := 0; i < len(list); i++ {
+				if walk(list[i], fn) {
+					return true
+				}
+			}
+			return false
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+	return false
+}
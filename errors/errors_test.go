@@ -89,6 +89,87 @@ func TestIs(t *testing.T) {
 	}
 }
 
+func TestIsWrapped(t *testing.T) {
+	var err = Wrap(RouteNotFount, "/missing")
+	assert.False(t, Is(err, StopPropagation))
+	assert.True(t, Is(err, RouteNotFount))
+	// the sentinel must survive being compared
+	assert.Equal(t, "route not found", RouteNotFount.Error())
+}
+
+type codeError struct{}
+
+func (codeError) Error() string { return "coded" }
+
+func (codeError) Code() uint32 { return 404 }
+
+func TestCodeOf(t *testing.T) {
+	code, ok := CodeOf(Wrap(fmt.Errorf("wrap: %w", codeError{}), "outer"))
+	assert.True(t, ok)
+	assert.Equal(t, uint32(404), code)
+
+	_, ok = CodeOf(New("plain"))
+	assert.False(t, ok)
+}
+
+func TestCodeError(t *testing.T) {
+	var NotEnough = Register(10001, 402, "not enough credit")
+	assert.Equal(t, NotEnough, Lookup(10001))
+	assert.Panics(t, func() { Register(10001, 402, "again") })
+
+	var err = Wrap(NotEnough.WithCause(fmt.Errorf("balance 3 < 5")), "charge")
+	assert.True(t, Is(err, NotEnough))
+	assert.False(t, Is(err, Invalid))
+	assert.Equal(t, "charge: not enough credit: balance 3 < 5", err.Error())
+	assert.Equal(t, "not enough credit", MessageOf(err))
+
+	code, _ := CodeOf(err)
+	status, _ := StatusOf(err)
+	assert.Equal(t, uint32(10001), code)
+	assert.Equal(t, 402, status)
+}
+
+type pathError struct{ path string }
+
+func (e *pathError) Error() string { return "bad path " + e.path }
+
+func TestAs(t *testing.T) {
+	var err = Wrap(fmt.Errorf("open: %w", &pathError{path: "/tmp"}), "load")
+
+	var target *pathError
+	assert.True(t, As(err, &target))
+	assert.Equal(t, "/tmp", target.path)
+
+	var coded *CodeError
+	assert.False(t, As(err, &coded))
+	assert.True(t, As(Wrap(Invalid, "name"), &coded))
+	assert.Equal(t, uint32(400), coded.Code())
+
+	// As must not consume the chain
+	assert.Equal(t, "load: open: bad path /tmp", err.Error())
+	assert.True(t, As(err, &target))
+}
+
+func TestJoin(t *testing.T) {
+	assert.Nil(t, Join(nil, nil))
+
+	var err = Join(New("first"), nil, Wrap(Timeout, "second"))
+	assert.Equal(t, "first\nsecond: timeout", err.Error())
+	assert.True(t, Is(err, Timeout))
+	assert.False(t, Is(err, Invalid))
+	assert.Contains(t, fmt.Sprintf("%+v", err), "errors_test.go")
+}
+
+func TestMarshalJSON(t *testing.T) {
+	var err = Wrap(New("inner"), "outer")
+
+	bts, e := err.(*Error).MarshalJSON()
+	assert.Nil(t, e)
+	assert.Contains(t, string(bts), `"message":"outer: inner"`)
+	assert.Contains(t, string(bts), `"chain":["outer","inner"]`)
+	assert.Contains(t, string(bts), `"func":"errors.TestMarshalJSON"`)
+}
+
 func BenchmarkIsNil(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		kitty2.IsNil(&Error{})
@@ -15,6 +15,7 @@ const (
 	AccessControlAllowMethods = "Access-Control-Allow-Methods"
 	AccessControlAllowHeaders = "Access-Control-Allow-Headers"
 
+	Forwarded       = "Forwarded"
 	XForwardedFor   = "X-Forwarded-For"
 	XForwardedHost  = "X-Forwarded-Host"
 	XForwardedProto = "X-Forwarded-Proto"
@@ -34,6 +35,8 @@ const (
 	UserAgent      = "User-Agent"
 	Nocache        = "no-cache"
 	LastEventID    = "Last-Event-ID"
+	RetryAfter     = "Retry-After"
+	Allow          = "Allow"
 
 	Host                   = "Host"
 	ContentType            = "Content-Type"
@@ -52,6 +55,7 @@ const (
 	ApplicationFormUrlencoded = "application/x-www-form-urlencoded"
 	ApplicationProtobuf       = "application/x-protobuf"
 	ApplicationJson           = "application/json"
+	ApplicationProblemJson    = "application/problem+json"
 	ApplicationOctetStream    = "application/octet-stream"
 	ApplicationXml            = "application/xml"
 	ApplicationZip            = "application/zip"
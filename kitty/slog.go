@@ -0,0 +1,134 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 21:20
+**/
+
+package kitty
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// NewLogger returns a Logger backed by l, or by slog.Default when l is nil.
+func NewLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{logger: l}
+}
+
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+func (s *SlogLogger) Slog() *slog.Logger {
+	return s.logger
+}
+
+func (s *SlogLogger) With(args ...any) Logger {
+	return &SlogLogger{logger: s.logger.With(args...)}
+}
+
+func (s *SlogLogger) Errorf(format string, args ...any) {
+	s.logger.Error(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Warningf(format string, args ...any) {
+	s.logger.Warn(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Infof(format string, args ...any) {
+	s.logger.Info(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Debugf(format string, args ...any) {
+	s.logger.Debug(fmt.Sprintf(format, args...))
+}
+
+func (s *SlogLogger) Error(args ...any) {
+	s.logger.Error(sprint(args...))
+}
+
+func (s *SlogLogger) Warning(args ...any) {
+	s.logger.Warn(sprint(args...))
+}
+
+func (s *SlogLogger) Info(args ...any) {
+	s.logger.Info(sprint(args...))
+}
+
+func (s *SlogLogger) Debug(args ...any) {
+	s.logger.Debug(sprint(args...))
+}
+
+// With adds key value pairs to logger when it knows how to,
+// any other Logger is returned as is.
+func With(logger Logger, args ...any) Logger {
+	if l, ok := logger.(interface{ With(args ...any) Logger }); ok {
+		return l.With(args...)
+	}
+	return logger
+}
+
+// Lazy is With put off until the first log,
+// for loggers made per message that are seldom written to.
+func Lazy(logger Logger, args ...any) Logger {
+	return &lazyLogger{parent: logger, args: args}
+}
+
+type lazyLogger struct {
+	once   sync.Once
+	parent Logger
+	args   []any
+	logger Logger
+}
+
+func (l *lazyLogger) get() Logger {
+	l.once.Do(func() { l.logger = With(l.parent, l.args...) })
+	return l.logger
+}
+
+func (l *lazyLogger) With(args ...any) Logger {
+	return Lazy(l.parent, append(append([]any{}, l.args...), args...)...)
+}
+
+func (l *lazyLogger) Errorf(format string, args ...any) {
+	l.get().Errorf(format, args...)
+}
+
+func (l *lazyLogger) Warningf(format string, args ...any) {
+	l.get().Warningf(format, args...)
+}
+
+func (l *lazyLogger) Infof(format string, args ...any) {
+	l.get().Infof(format, args...)
+}
+
+func (l *lazyLogger) Debugf(format string, args ...any) {
+	l.get().Debugf(format, args...)
+}
+
+func (l *lazyLogger) Error(args ...any) {
+	l.get().Error(args...)
+}
+
+func (l *lazyLogger) Warning(args ...any) {
+	l.get().Warning(args...)
+}
+
+func (l *lazyLogger) Info(args ...any) {
+	l.get().Info(args...)
+}
+
+func (l *lazyLogger) Debug(args ...any) {
+	l.get().Debug(args...)
+}
+
+// sprint joins like fmt.Println does.
+func sprint(args ...any) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
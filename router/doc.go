@@ -0,0 +1,22 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 02:40
+**/
+
+package router
+
+// Doc describes a route for generated api documents, the router only carries it.
+// Params is a struct whose fields use param, query and header tags,
+// Request and Response are the payload types.
+type Doc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	OperationID string
+	Deprecated  bool
+
+	Params   any
+	Request  any
+	Response any
+}
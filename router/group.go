@@ -11,7 +11,7 @@
 package router
 
 import (
-	"strings"
+	"time"
 	"unsafe"
 )
 
@@ -21,7 +21,10 @@ type Group[T any, P any] struct {
 	data   P
 	before []Before[T]
 	after  []After[T]
+	wrap   []Wrap[T]
 	router *Router[T, P]
+
+	timeout time.Duration
 }
 
 func (g *Group[T, P]) Before(before ...Before[T]) *Group[T, P] {
@@ -34,6 +37,17 @@ func (g *Group[T, P]) After(after ...After[T]) *Group[T, P] {
 	return g
 }
 
+// Wrap adds middleware around every handler of the group, the first one is the outermost.
+func (g *Group[T, P]) Wrap(wrap ...Wrap[T]) *Group[T, P] {
+	g.wrap = append(g.wrap, wrap...)
+	return g
+}
+
+func (g *Group[T, P]) CancelWrap() *Group[T, P] {
+	g.wrap = nil
+	return g
+}
+
 func (g *Group[T, P]) RemoveBefore(before ...Before[T]) *Group[T, P] {
 	for i := 0; i < len(before); i++ {
 		for j := 0; j < len(g.before); j++ {
@@ -71,15 +85,14 @@ func (g *Group[T, P]) CancelAfter() *Group[T, P] {
 }
 
 func (g *Group[T, P]) Remove(path ...string) {
-	if g.router.trie == nil {
+	var router = g.router.lock()
+	defer router.mux.Unlock()
+	router.generation.Add(1)
+	if router.trie == nil {
 		return
 	}
 	for i := 0; i < len(path); i++ {
-		var dp = g.path + path[i]
-		if !g.router.StrictMode {
-			dp = strings.ToLower(dp)
-		}
-		g.router.trie.Delete(dp)
+		router.trie.remove(router.formatPath(g.path + path[i]))
 	}
 }
 
@@ -87,9 +100,13 @@ func (g *Group[T, P]) Handler(fn func(handler *Handler[T, P])) {
 	fn(&Handler[T, P]{group: &Group[T, P]{
 		path:   g.path,
 		desc:   append([]string{}, g.desc...),
+		data:   g.data,
 		before: append([]Before[T]{}, g.before...),
 		after:  append([]After[T]{}, g.after...),
+		wrap:   append([]Wrap[T]{}, g.wrap...),
 		router: g.router,
+
+		timeout: g.timeout,
 	}})
 }
 
@@ -97,12 +114,28 @@ func (g *Group[T, P]) Create() *Handler[T, P] {
 	return &Handler[T, P]{group: &Group[T, P]{
 		path:   g.path,
 		desc:   append([]string{}, g.desc...),
+		data:   g.data,
 		before: append([]Before[T]{}, g.before...),
 		after:  append([]After[T]{}, g.after...),
+		wrap:   append([]Wrap[T]{}, g.wrap...),
 		router: g.router,
+
+		timeout: g.timeout,
 	}}
 }
 
+// Timeout bounds every handler of the group, 0 falls back to the server.
+func (g *Group[T, P]) Timeout(timeout time.Duration) *Group[T, P] {
+	g.timeout = timeout
+	return g
+}
+
+// Data is inherited by every route of the group, Route.Data overrides it.
+func (g *Group[T, P]) Data(data P) *Group[T, P] {
+	g.data = data
+	return g
+}
+
 func (g *Group[T, P]) Desc(desc ...string) *Group[T, P] {
 	g.desc = append(g.desc, desc...)
 	return g
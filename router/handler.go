@@ -22,9 +22,12 @@ type MethodsHandler[T any,P any] struct {
 
 func (m *MethodsHandler[T,P]) Route(path ...string) *Route[T,P] {
 	return &Route[T,P]{
-		method: m.method, path: path, group: m.group,
+		method: m.method, path: path, group: m.group, data: m.group.data,
 		before: append([]Before[T]{}, m.group.before...),
 		after:  append([]After[T]{}, m.group.after...),
+		wrap:   append([]Wrap[T]{}, m.group.wrap...),
+
+		timeout: m.group.timeout,
 	}
 }
 
@@ -36,17 +39,24 @@ func (rh *Handler[T,P]) Group(path ...string) *Group[T,P] {
 	return &Group[T,P]{
 		path:   rh.group.path + strings.Join(path, ""),
 		desc:   append([]string{}, rh.group.desc...),
+		data:   rh.group.data,
 		before: append([]Before[T]{}, rh.group.before...),
 		after:  append([]After[T]{}, rh.group.after...),
+		wrap:   append([]Wrap[T]{}, rh.group.wrap...),
 		router: rh.group.router,
+
+		timeout: rh.group.timeout,
 	}
 }
 
 func (rh *Handler[T,P]) Route(path ...string) *Route[T,P] {
 	return &Route[T,P]{
-		method: []string{"GET"}, path: path, group: rh.group,
+		method: []string{"GET"}, path: path, group: rh.group, data: rh.group.data,
 		before: append([]Before[T]{}, rh.group.before...),
 		after:  append([]After[T]{}, rh.group.after...),
+		wrap:   append([]Wrap[T]{}, rh.group.wrap...),
+
+		timeout: rh.group.timeout,
 	}
 }
 
@@ -91,14 +101,13 @@ func (rh *Handler[T,P]) Trace(path ...string) *Route[T,P] {
 }
 
 func (rh *Handler[T,P]) Remove(path ...string) {
-	if rh.group.router.trie == nil {
+	var router = rh.group.router.lock()
+	defer router.mux.Unlock()
+	router.generation.Add(1)
+	if router.trie == nil {
 		return
 	}
 	for i := 0; i < len(path); i++ {
-		var dp = rh.group.path + path[i]
-		if !rh.group.router.StrictMode {
-			dp = strings.ToLower(dp)
-		}
-		rh.group.router.trie.Delete(dp)
+		router.trie.remove(router.formatPath(rh.group.path + path[i]))
 	}
 }
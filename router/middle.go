@@ -17,3 +17,6 @@ type Func[T any] func(stream T) error
 type Before[T any] func(stream T) error
 
 type After[T any] func(stream T) error
+
+// Wrap runs around the handler, it calls next or returns without it.
+type Wrap[T any] func(next Func[T]) Func[T]
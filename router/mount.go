@@ -0,0 +1,118 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 04:40
+**/
+
+package router
+
+import (
+	"strings"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// Mount copies every route of other under prefix, routes added to other later are not seen.
+// The global Before and After of r run ahead of the chains other put in its nodes.
+// Nothing is mounted when a route conflicts with one r already has.
+func (r *Router[T, P]) Mount(prefix string, other *Router[T, P]) error {
+	var nodes = other.GetAllRouters()
+
+	r = r.lock()
+	defer r.mux.Unlock()
+	r.generation.Add(1)
+
+	var taken = make(map[string]string)
+	var names = make(map[string]string)
+	var exists = r.allRouters()
+	for i := 0; i < len(exists); i++ {
+		taken[r.conflictKey(string(exists[i].Route))] = string(exists[i].Route)
+		if exists[i].Name != "" {
+			names[exists[i].Name] = string(exists[i].Route)
+		}
+	}
+
+	var conflicts []string
+	for i := 0; i < len(nodes); i++ {
+		var route = prefix + string(nodes[i].Route)
+		var key = r.conflictKey(route)
+		if exist, ok := taken[key]; ok {
+			conflicts = append(conflicts, route+" with "+exist)
+			continue
+		}
+		taken[key] = route
+		if name := nodes[i].Name; name != "" {
+			if exist, ok := names[name]; ok {
+				conflicts = append(conflicts, "name "+name+" of "+route+" with "+exist)
+				continue
+			}
+			names[name] = route
+		}
+	}
+
+	if len(conflicts) != 0 {
+		return errors.Wrap(errors.RouteConflict, strings.Join(conflicts, ", "))
+	}
+
+	if r.trie == nil {
+		r.trie = newTree[T, P]()
+	}
+
+	if r.mounts == nil {
+		r.mounts = make(map[string][]string)
+	}
+
+	for i := 0; i < len(nodes); i++ {
+		var node = *nodes[i]
+		var route = prefix + string(node.Route)
+
+		node.Route = []byte(route)
+		node.Desc = append([]string{}, node.Desc...)
+		node.Tags = append([]string{}, node.Tags...)
+		node.Before = append(append([]Before[T]{}, r.globalBefore...), node.Before...)
+		node.After = append(append([]After[T]{}, r.globalAfter...), node.After...)
+
+		r.trie.insert(r.formatPath(route), &node)
+
+		r.mounts[prefix] = append(r.mounts[prefix], route)
+	}
+
+	return nil
+}
+
+// Unmount removes the routes mounted under prefix, false when there are none.
+func (r *Router[T, P]) Unmount(prefix string) bool {
+	r = r.lock()
+	defer r.mux.Unlock()
+	r.generation.Add(1)
+
+	var routes, ok = r.mounts[prefix]
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < len(routes); i++ {
+		r.trie.remove(r.formatPath(routes[i]))
+	}
+
+	delete(r.mounts, prefix)
+
+	return true
+}
+
+// conflictKey is the route as the tree sees it, /a/:id and /a/:name are the same.
+func (r *Router[T, P]) conflictKey(route string) string {
+	var segments = parseSegments(r.formatPath(route))
+	var res = make([]string, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		switch segments[i].kind {
+		case paramSegment:
+			res = append(res, ":<"+segments[i].constraint+">")
+		case catchAllSegment:
+			res = append(res, "*")
+		default:
+			res = append(res, segments[i].value)
+		}
+	}
+	return strings.Join(res, "/")
+}
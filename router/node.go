@@ -10,6 +10,8 @@
 
 package router
 
+import "time"
+
 type Node[T any, P any] struct {
 	Data     P
 	Info     string
@@ -19,4 +21,9 @@ type Node[T any, P any] struct {
 	Before   []Before[T]
 	After    []After[T]
 	Method   []string
+	Timeout  time.Duration
+	// Tags is the desc of the group the route was made in
+	Tags []string
+	Doc  *Doc
+	Name string
 }
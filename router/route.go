@@ -11,12 +11,13 @@
 package router
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/lemonyxk/caller"
-	"github.com/lemonyxk/structure/trie"
 )
 
 type Route[T any, P any] struct {
@@ -24,9 +25,14 @@ type Route[T any, P any] struct {
 	method []string
 	before []Before[T]
 	after  []After[T]
+	wrap   []Wrap[T]
 	group  *Group[T, P]
 	desc   []string
 	data   P
+
+	timeout time.Duration
+	doc     *Doc
+	name    string
 }
 
 func (r *Route[T, P]) Desc(desc ...string) *Route[T, P] {
@@ -62,6 +68,17 @@ func (r *Route[T, P]) After(after ...After[T]) *Route[T, P] {
 	return r
 }
 
+// Wrap adds middleware around the handler, inside the ones of the group.
+func (r *Route[T, P]) Wrap(wrap ...Wrap[T]) *Route[T, P] {
+	r.wrap = append(r.wrap, wrap...)
+	return r
+}
+
+func (r *Route[T, P]) CancelWrap() *Route[T, P] {
+	r.wrap = nil
+	return r
+}
+
 func (r *Route[T, P]) RemoveAfter(after ...After[T]) *Route[T, P] {
 	for i := 0; i < len(after); i++ {
 		for j := 0; j < len(r.after); j++ {
@@ -85,6 +102,24 @@ func (r *Route[T, P]) Data(data P) *Route[T, P] {
 	return r
 }
 
+// Doc attaches metadata for api documents.
+func (r *Route[T, P]) Doc(doc *Doc) *Route[T, P] {
+	r.doc = doc
+	return r
+}
+
+// Name lets Router.URL build the route back, with several paths it names the first one.
+func (r *Route[T, P]) Name(name string) *Route[T, P] {
+	r.name = name
+	return r
+}
+
+// Timeout bounds the handler, 0 falls back to the group and then the server.
+func (r *Route[T, P]) Timeout(timeout time.Duration) *Route[T, P] {
+	r.timeout = timeout
+	return r
+}
+
 func (r *Route[T, P]) Handler(fn Func[T]) {
 
 	if len(r.path) == 0 {
@@ -106,6 +141,21 @@ func (r *Route[T, P]) Handler(fn Func[T]) {
 		g = new(Group[T, P])
 	}
 
+	// composed once here, so calling the handler costs nothing more
+	for i := len(r.wrap) - 1; i >= 0; i-- {
+		fn = r.wrap[i](fn)
+	}
+
+	router = router.lock()
+	defer router.mux.Unlock()
+	router.generation.Add(1)
+
+	if r.name != "" {
+		if entry := router.named(r.name); entry != nil {
+			panic(fmt.Sprintf("route name %s is already used by %s", r.name, entry.Data.Route))
+		}
+	}
+
 	for i := 0; i < len(r.path); i++ {
 
 		var originPath = g.path + r.path[i]
@@ -113,7 +163,7 @@ func (r *Route[T, P]) Handler(fn Func[T]) {
 		var path = router.formatPath(originPath)
 
 		if router.trie == nil {
-			router.trie = trie.New[*Node[T, P]]()
+			router.trie = newTree[T, P]()
 		}
 
 		var cba = &Node[T, P]{}
@@ -133,7 +183,17 @@ func (r *Route[T, P]) Handler(fn Func[T]) {
 
 		cba.Data = r.data
 
-		router.trie.Insert(path, cba)
+		cba.Timeout = r.timeout
+
+		cba.Tags = append([]string{}, g.desc...)
+
+		cba.Doc = r.doc
+
+		if i == 0 {
+			cba.Name = r.name
+		}
+
+		router.trie.insert(path, cba)
 	}
 
 }
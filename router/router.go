@@ -12,32 +12,116 @@ package router
 
 import (
 	"strings"
-
-	"github.com/lemonyxk/structure/trie"
+	"sync"
+	"sync/atomic"
+	"unicode"
 )
 
 type Router[T any, P any] struct {
 	StrictMode   bool
-	trie         *trie.Node[*Node[T, P]]
+	trie         *tree[T, P]
 	globalAfter  []After[T]
 	globalBefore []Before[T]
+	mounts       map[string][]string
+	// mux guards the trie, routes may be added and removed while serving
+	mux sync.RWMutex
+	// owner is the router a Replace handed the routes to,
+	// groups made during the Replace keep adding to it
+	owner atomic.Pointer[Router[T, P]]
+	// generation counts the changes to the routes
+	generation atomic.Uint64
+}
+
+// Generation changes whenever a route is added, removed, mounted or replaced,
+// so whatever is built from the routes knows when to build again.
+func (r *Router[T, P]) Generation() uint64 {
+	return r.root().generation.Load()
+}
+
+// lock write locks the router holding the routes of r and returns it.
+func (r *Router[T, P]) lock() *Router[T, P] {
+	for {
+		var root = r.root()
+		root.mux.Lock()
+		if root.owner.Load() == nil {
+			return root
+		}
+		root.mux.Unlock()
+	}
+}
+
+// rlock is lock for reading.
+func (r *Router[T, P]) rlock() *Router[T, P] {
+	for {
+		var root = r.root()
+		root.mux.RLock()
+		if root.owner.Load() == nil {
+			return root
+		}
+		root.mux.RUnlock()
+	}
+}
+
+func (r *Router[T, P]) root() *Router[T, P] {
+	for owner := r.owner.Load(); owner != nil; owner = r.owner.Load() {
+		r = owner
+	}
+	return r
 }
 
 func (r *Router[T, P]) SetGlobalBefore(before ...Before[T]) {
+	r = r.lock()
+	defer r.mux.Unlock()
 	r.globalBefore = append(r.globalBefore, before...)
 }
 
 func (r *Router[T, P]) SetGlobalAfter(after ...After[T]) {
+	r = r.lock()
+	defer r.mux.Unlock()
 	r.globalAfter = append(r.globalAfter, after...)
 }
 
+// Replace builds a new route table with fn and swaps it in at once,
+// requests see either the old table or the new one.
+// The new router starts with the global Before and After of r.
+func (r *Router[T, P]) Replace(fn func(router *Router[T, P])) {
+	var next = &Router[T, P]{StrictMode: r.StrictMode}
+
+	var root = r.rlock()
+	next.globalBefore = append([]Before[T]{}, root.globalBefore...)
+	next.globalAfter = append([]After[T]{}, root.globalAfter...)
+	root.mux.RUnlock()
+
+	fn(next)
+
+	next.mux.Lock()
+	defer next.mux.Unlock()
+
+	root = r.lock()
+	defer root.mux.Unlock()
+
+	root.trie = next.trie
+	root.mounts = next.mounts
+
+	// groups of next made in fn add to root from now on
+	next.trie = nil
+	next.mounts = nil
+	next.owner.Store(root)
+	root.generation.Add(1)
+}
+
 func (r *Router[T, P]) GetAllRouters() []*Node[T, P] {
+	r = r.rlock()
+	defer r.mux.RUnlock()
+	return r.allRouters()
+}
+
+func (r *Router[T, P]) allRouters() []*Node[T, P] {
 	var res []*Node[T, P]
-	var tires = r.trie.GetAllValue()
-	for i := 0; i < len(tires); i++ {
-		res = append(res, tires[i].Data)
+	if r.trie == nil {
+		return res
 	}
-	return res
+	return r.trie.all(res)
 }
 
 func (r *Router[T, P]) Group(path ...string) *Group[T, P] {
@@ -48,14 +132,13 @@ func (r *Router[T, P]) Group(path ...string) *Group[T, P] {
 }
 
 func (r *Router[T, P]) Remove(path ...string) {
+	r = r.lock()
+	defer r.mux.Unlock()
+	r.generation.Add(1)
 	if r.trie == nil {
 		return
 	}
-	var dp = strings.Join(path, "")
-	if !r.StrictMode {
-		dp = strings.ToLower(dp)
-	}
-	r.trie.Delete(dp)
+	r.trie.remove(r.formatPath(strings.Join(path, "")))
 }
 
 func (r *Router[T, P]) Create() *Handler[T, P] {
@@ -79,24 +162,56 @@ func (m *MethodsRouter[T, P]) Route(path ...string) *Route[T, P] {
 	return (&Handler[T, P]{group: m.router.Group("")}).Method(m.method...).Route(path...)
 }
 
-func (r *Router[T, P]) GetRoute(path string) (*trie.Node[*Node[T, P]], string) {
+// GetRoute is Lookup under its old name.
+//
+// Deprecated: GetRoute returned a *trie.Node before params got constraints,
+// it now returns the *Entry of Lookup, which keeps Data, Path and ParseParams.
+func (r *Router[T, P]) GetRoute(path string) (*Entry[T, P], string) {
+	return r.Lookup(path)
+}
+
+// Lookup finds the route of path and returns it with the path
+// to hand to ParseParams.
+func (r *Router[T, P]) Lookup(path string) (*Entry[T, P], string) {
+	r = r.rlock()
+	defer r.mux.RUnlock()
+
 	if r.trie == nil {
 		return nil, ""
 	}
 
-	path = r.formatPath(path)
-
-	var t = r.trie.GetValue(path)
-	if t == nil {
+	// only the static segments of the pattern are lower cased,
+	// params keep the case they were sent with
+	var entry = r.trie.lookup(path, !r.StrictMode)
+	if entry == nil {
 		return nil, ""
 	}
 
-	return t, path
+	return entry, path
 }
 
+// formatPath lower cases the path unless StrictMode,
+// constraints in <> are left alone.
 func (r *Router[T, P]) formatPath(path string) string {
-	if !r.StrictMode {
-		path = strings.ToLower(path)
+	if r.StrictMode || !strings.ContainsRune(path, '<') {
+		if !r.StrictMode {
+			path = strings.ToLower(path)
+		}
+		return path
+	}
+
+	var builder strings.Builder
+	var depth = 0
+	for _, c := range path {
+		switch {
+		case c == '<':
+			depth++
+		case c == '>' && depth > 0:
+			depth--
+		case depth == 0:
+			c = unicode.ToLower(c)
+		}
+		builder.WriteRune(c)
 	}
-	return path
+	return builder.String()
 }
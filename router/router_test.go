@@ -12,9 +12,13 @@ package router
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"unsafe"
 
+	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/structure/trie"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -307,6 +311,377 @@ func Test_Router_After(t *testing.T) {
 	assert.True(t, equal(gg.after[0], b1) && len(gg.after) == 1, "RemoveAfter failed", len(gg.after))
 }
 
+func Test_Router_GetAllRouters(t *testing.T) {
+	var r = &Router[int, any]{}
+	assert.Empty(t, r.GetAllRouters())
+
+	var f = func(stream int) error { return nil }
+	var doc = &Doc{Summary: "get"}
+	r.Create().Group("/user").Desc("user").Handler(func(handler *Handler[int, any]) {
+		handler.Get("/:id").Desc("get").Doc(doc).Handler(f)
+		handler.Get("/:id/name").Handler(f)
+	})
+
+	var nodes = r.GetAllRouters()
+	assert.Equal(t, 2, len(nodes))
+
+	for i := 0; i < len(nodes); i++ {
+		assert.Equal(t, []string{"user"}, nodes[i].Tags)
+		if string(nodes[i].Route) == "/user/:id" {
+			assert.Equal(t, doc, nodes[i].Doc)
+			assert.Equal(t, []string{"user", "get"}, nodes[i].Desc)
+		}
+	}
+}
+
+func Test_Router_Mount(t *testing.T) {
+	var order []string
+	var before = func(name string) Before[int] {
+		return func(stream int) error { order = append(order, name); return nil }
+	}
+	var f = func(stream int) error { return nil }
+
+	var r = &Router[int, any]{}
+	r.SetGlobalBefore(before("root"))
+	r.Create().Get("/user/:id").Handler(f)
+
+	var module = &Router[int, any]{}
+	module.SetGlobalBefore(before("module"))
+	module.Create().Group("/user").Desc("user").Handler(func(handler *Handler[int, any]) {
+		handler.Get("/:name").Desc("get").Data("data").Handler(f)
+		handler.Post("/list").Handler(f)
+	})
+
+	assert.True(t, errors.Is(r.Mount("", module), errors.RouteConflict))
+	assert.Equal(t, 1, len(r.GetAllRouters()))
+
+	assert.Nil(t, r.Mount("/api", module))
+
+	a, _ := r.GetRoute("/api/user/1")
+	assert.True(t, a != nil)
+	assert.Equal(t, "/api/user/:name", string(a.Data.Route))
+	assert.Equal(t, []string{"user", "get"}, a.Data.Desc)
+	assert.Equal(t, "data", a.Data.Data)
+
+	for i := 0; i < len(a.Data.Before); i++ {
+		_ = a.Data.Before[i](0)
+	}
+	assert.Equal(t, []string{"root", "module"}, order)
+
+	assert.True(t, errors.Is(r.Mount("/api", module), errors.RouteConflict))
+
+	assert.True(t, r.Unmount("/api"))
+	assert.False(t, r.Unmount("/api"))
+
+	a, _ = r.GetRoute("/api/user/list")
+	assert.True(t, a == nil)
+	assert.Equal(t, 1, len(r.GetAllRouters()))
+
+	assert.Nil(t, r.Mount("/api", module))
+	assert.Equal(t, 3, len(r.GetAllRouters()))
+}
+
+func Test_Router_Concurrent(t *testing.T) {
+	var r = &Router[int, any]{}
+	var f = func(stream int) error { return nil }
+	r.Create().Get("/static").Handler(f)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				var path = fmt.Sprintf("/live/%d/%d", i, j)
+				r.Create().Get(path).Handler(f)
+				r.Remove(path)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a, _ := r.GetRoute("/static")
+				assert.True(t, a != nil)
+				_ = r.GetAllRouters()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var group *Group[int, any]
+	r.Replace(func(router *Router[int, any]) {
+		router.Create().Get("/v2").Handler(f)
+		group = router.Group("/late")
+		a, _ := r.GetRoute("/static")
+		assert.True(t, a != nil, "old table is served until fn returns")
+	})
+
+	a, _ := r.GetRoute("/static")
+	assert.True(t, a == nil)
+	a, _ = r.GetRoute("/v2")
+	assert.True(t, a != nil)
+
+	// a group kept from fn adds to r
+	group.Create().Get("/v3").Handler(f)
+	a, _ = r.GetRoute("/late/v3")
+	assert.True(t, a != nil)
+	group.Remove("/v3")
+	a, _ = r.GetRoute("/late/v3")
+	assert.True(t, a == nil)
+}
+
+func Test_Router_Constraint(t *testing.T) {
+	var r = &Router[int, any]{}
+	var f = func(stream int) error { return nil }
+	var g = r.Create()
+	g.Get("/user/:id<int>").Handler(f)
+	g.Get("/user/:slug").Handler(f)
+	g.Get("/post/:slug<[a-z-]+>").Handler(f)
+	g.Get("/file/:uuid<uuid>").Handler(f)
+	g.Get("/static/*path").Handler(f)
+
+	a, b := r.GetRoute("/user/12")
+	assert.Equal(t, "/user/:id<int>", a.Path)
+	assert.Equal(t, map[string]string{"id": "12"}, a.ParseParams(b))
+
+	e, p := r.Lookup("/user/12")
+	assert.True(t, e == a && p == b)
+
+	a, b = r.GetRoute("/user/lemon")
+	assert.Equal(t, "/user/:slug", a.Path)
+	assert.Equal(t, map[string]string{"slug": "lemon"}, a.ParseParams(b))
+
+	a, _ = r.GetRoute("/post/hello-world")
+	assert.Equal(t, "/post/:slug<[a-z-]+>", a.Path)
+	a, _ = r.GetRoute("/post/hello_world")
+	assert.True(t, a == nil)
+
+	a, _ = r.GetRoute("/file/123e4567-e89b-12d3-a456-426614174000")
+	assert.True(t, a != nil)
+	a, _ = r.GetRoute("/file/123")
+	assert.True(t, a == nil)
+
+	a, b = r.GetRoute("/static/css/app.css")
+	assert.Equal(t, "/static/*path", a.Path)
+	assert.Equal(t, map[string]string{"path": "css/app.css"}, a.ParseParams(b))
+
+	assert.Panics(t, func() { g.Get("/user/:other<int>").Handler(f) })
+
+	// static segments ignore case, params and constraints do not
+	g.Get("/Country/:code<[A-Z]+>").Handler(f)
+
+	a, b = r.GetRoute("/country/FR")
+	assert.Equal(t, "/country/:code<[A-Z]+>", a.Path)
+	assert.Equal(t, map[string]string{"code": "FR"}, a.ParseParams(b))
+	a, _ = r.GetRoute("/COUNTRY/fr")
+	assert.True(t, a == nil)
+
+	a, b = r.GetRoute("/User/Lemon")
+	assert.Equal(t, map[string]string{"slug": "Lemon"}, a.ParseParams(b))
+}
+
+func Test_Router_URL(t *testing.T) {
+	var r = &Router[int, any]{}
+	var f = func(stream int) error { return nil }
+	r.Group("/api").Handler(func(handler *Handler[int, any]) {
+		handler.Get("/user/:id<int>").Name("user.show").Handler(f)
+		handler.Get("/static/*path").Name("static").Handler(f)
+	})
+
+	var url, err = r.URL("user.show", "id", 12)
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/user/12", url)
+
+	_, err = r.URL("user.show")
+	assert.True(t, errors.Is(err, errors.MissingParam))
+
+	_, err = r.URL("user.show", "id", "abc")
+	assert.True(t, errors.Is(err, errors.Invalid))
+
+	_, err = r.URL("user.list")
+	assert.True(t, errors.Is(err, errors.RouteNameNotFound))
+
+	url, err = r.URL("static", "path", "css/a b.css")
+	assert.Nil(t, err)
+	assert.Equal(t, "/api/static/css/a%20b.css", url)
+
+	url, _ = r.URL("static")
+	assert.Equal(t, "/api/static", url)
+
+	assert.Panics(t, func() { r.Create().Get("/other").Name("static").Handler(f) })
+
+	var module = &Router[int, any]{}
+	module.Create().Route("/login").Name("login").Handler(f)
+	assert.Nil(t, r.Mount("/v1", module))
+
+	event, err := r.Event("login")
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/login", event)
+
+	assert.True(t, errors.Is(r.Mount("/v2", module), errors.RouteConflict))
+
+	// removing a route frees its name
+	r.Remove("/api/user/:id<int>")
+	_, err = r.URL("user.show", "id", 12)
+	assert.True(t, errors.Is(err, errors.RouteNameNotFound))
+	r.Create().Get("/User/:id<int>").Name("user.show").Handler(f)
+	url, _ = r.URL("user.show", "id", 12)
+	assert.Equal(t, "/User/12", url)
+}
+
+func Test_Router_Wrap(t *testing.T) {
+	var r = &Router[int, any]{}
+	var order []string
+	var wrap = func(name string) Wrap[int] {
+		return func(next Func[int]) Func[int] {
+			return func(stream int) error {
+				order = append(order, name+" in")
+				var err = next(stream)
+				order = append(order, name+" out")
+				return err
+			}
+		}
+	}
+
+	var fail = errors.New("fail")
+
+	r.Group("/api").Wrap(wrap("group")).Handler(func(handler *Handler[int, any]) {
+		handler.Get("/user").Wrap(wrap("route")).Handler(func(stream int) error {
+			order = append(order, "handler")
+			return fail
+		})
+		handler.Get("/plain").CancelWrap().Handler(func(stream int) error { return nil })
+	})
+
+	a, _ := r.GetRoute("/api/user")
+	assert.True(t, a.Data.Function(0) == fail)
+	assert.Equal(t, []string{"group in", "route in", "handler", "route out", "group out"}, order)
+
+	order = nil
+	a, _ = r.GetRoute("/api/plain")
+	assert.Nil(t, a.Data.Function(0))
+	assert.Equal(t, 0, len(order))
+}
+
+// the route tables of the trie the router used before the tree,
+// only patterns it supported
+var trieTables = [][]string{
+	{"/", "/user", "/user/:id", "/user/:id/posts", "/user/list", "/static/*", "/a/b/c"},
+	{"/User/:ID", "/Files/*path"},
+	{"/user/list/x", "/user/:id/y"},
+}
+
+// trieLookup is how the router found a route with lemonyxk/structure/trie.
+func trieLookup(routes []string, strict bool, path string) (string, map[string]string) {
+	var t = trie.New[string]()
+	for i := 0; i < len(routes); i++ {
+		var route = routes[i]
+		if !strict {
+			route = strings.ToLower(route)
+		}
+		t.Insert(route, routes[i])
+	}
+	if !strict {
+		path = strings.ToLower(path)
+	}
+	var node = t.GetValue(path)
+	if node == nil || !node.HasValue {
+		return "", nil
+	}
+	var params = node.ParseParams(path)
+	if len(params) == 0 {
+		params = nil
+	}
+	return node.Data, params
+}
+
+func treeLookup(routes []string, strict bool, path string) (string, map[string]string) {
+	var r = &Router[int, any]{StrictMode: strict}
+	for i := 0; i < len(routes); i++ {
+		r.Create().Get(routes[i]).Handler(func(stream int) error { return nil })
+	}
+	var entry, p = r.Lookup(path)
+	if entry == nil {
+		return "", nil
+	}
+	var params = entry.ParseParams(p)
+	if len(params) == 0 {
+		params = nil
+	}
+	return string(entry.Data.Route), params
+}
+
+func Test_Router_TreeMatchesTrie(t *testing.T) {
+	var cases = []struct {
+		table  int
+		strict bool
+		path   string
+	}{
+		{0, false, "/"},
+		{0, false, "/user"},
+		{0, false, "/USER"},
+		{0, false, "/user/12"},
+		{0, false, "/user/12/posts"},
+		{0, false, "/user/list"},
+		{0, false, "/User/List"},
+		{0, false, "/static/a/b"},
+		{0, false, "/a/b/c"},
+		{0, false, "/a//b/c"},
+		{0, false, "//user"},
+		{0, false, "/a/b"},
+		{0, true, "/user/12"},
+		{0, true, "/user/list"},
+		{0, true, "/static/a/b"},
+		{1, false, "/user/12"},
+		{1, false, "/USER/12"},
+		{1, true, "/User/12"},
+		{1, true, "/user/12"},
+		{2, false, "/user/list/x"},
+		{2, false, "/user/12/y"},
+		{2, false, "/user/12/x"},
+	}
+
+	for _, c := range cases {
+		var route, params = trieLookup(trieTables[c.table], c.strict, c.path)
+		var treeRoute, treeParams = treeLookup(trieTables[c.table], c.strict, c.path)
+		assert.Equal(t, route, treeRoute, c.path)
+		assert.Equal(t, params, treeParams, c.path)
+	}
+
+	// where the tree is meant to differ
+	var diffs = []struct {
+		table      int
+		strict     bool
+		path       string
+		trieRoute  string
+		trieParams map[string]string
+		treeRoute  string
+		treeParams map[string]string
+	}{
+		// a trailing slash is an empty segment and skipped
+		{0, false, "/user/", "", nil, "/user", nil},
+		// params keep the case they were sent with
+		{0, false, "/User/AbC", "/user/:id", map[string]string{"id": "abc"}, "/user/:id", map[string]string{"id": "AbC"}},
+		// the whole path has to match, the trie stopped at the last node it reached
+		{0, false, "/x", "/", nil, "", nil},
+		{0, false, "/user/list/y", "/user/list", nil, "", nil},
+		{1, false, "/user/12/posts", "/User/:ID", nil, "", nil},
+		// a catch-all hands over the rest of the path
+		{1, false, "/Files/a/b", "/Files/*path", nil, "/Files/*path", map[string]string{"path": "a/b"}},
+		// a static miss further down falls back to the param
+		{2, false, "/user/list/y", "", nil, "/user/:id/y", map[string]string{"id": "list"}},
+	}
+
+	for _, d := range diffs {
+		var route, params = trieLookup(trieTables[d.table], d.strict, d.path)
+		assert.Equal(t, d.trieRoute, route, d.path)
+		assert.Equal(t, d.trieParams, params, d.path)
+		route, params = treeLookup(trieTables[d.table], d.strict, d.path)
+		assert.Equal(t, d.treeRoute, route, d.path)
+		assert.Equal(t, d.treeParams, params, d.path)
+	}
+}
+
 func equal(a, b func(stream int) error) bool {
 	return *(*unsafe.Pointer)(unsafe.Pointer(&a)) == *(*unsafe.Pointer)(unsafe.Pointer(&b))
 }
@@ -0,0 +1,282 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 05:10
+**/
+
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// named constraints, anything else in <> is a regular expression
+var constraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"float": `-?[0-9]+(\.[0-9]+)?`,
+	"alpha": `[a-zA-Z]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+const (
+	staticSegment = iota
+	paramSegment
+	catchAllSegment
+)
+
+type segment struct {
+	kind       int
+	value      string
+	constraint string
+	pattern    *regexp.Regexp
+}
+
+func (s *segment) accept(value string) bool {
+	return s.pattern == nil || s.pattern.MatchString(value)
+}
+
+// parseSegments splits /user/:id<int>/*path, empty segments are ignored
+// and nothing after a catch-all counts.
+func parseSegments(route string) []segment {
+	var res []segment
+	var parts = strings.Split(route, "/")
+	for i := 0; i < len(parts); i++ {
+		var part = parts[i]
+		if part == "" {
+			continue
+		}
+
+		switch part[0] {
+		case ':':
+			var seg = segment{kind: paramSegment, value: part[1:]}
+			if index := strings.IndexByte(part, '<'); index > 0 && strings.HasSuffix(part, ">") {
+				seg.value = part[1:index]
+				seg.constraint = part[index+1 : len(part)-1]
+				var expr, ok = constraints[seg.constraint]
+				if !ok {
+					expr = seg.constraint
+				}
+				seg.pattern = regexp.MustCompile(`^(?:` + expr + `)$`)
+			}
+			res = append(res, seg)
+		case '*':
+			return append(res, segment{kind: catchAllSegment, value: part[1:]})
+		default:
+			res = append(res, segment{kind: staticSegment, value: part})
+		}
+	}
+	return res
+}
+
+func splitPath(path string) []string {
+	var parts = strings.Split(path, "/")
+	var res = parts[:0]
+	for i := 0; i < len(parts); i++ {
+		if parts[i] != "" {
+			res = append(res, parts[i])
+		}
+	}
+	return res
+}
+
+// Entry is a registered route, Path is the pattern it was registered with.
+type Entry[T any, P any] struct {
+	Data *Node[T, P]
+	Path string
+
+	segments []segment
+	// url is Data.Route split on /, kept for Router.URL
+	url []urlPart
+}
+
+// urlPart is a piece of the route between slashes, seg is nil for text.
+type urlPart struct {
+	text string
+	seg  *segment
+}
+
+// parseURL splits route like Router.URL writes it back,
+// the case and empty parts of route are kept.
+func parseURL(route string) []urlPart {
+	var parts = strings.Split(route, "/")
+	var res = make([]urlPart, len(parts))
+	for i := 0; i < len(parts); i++ {
+		res[i].text = parts[i]
+		if parts[i] == "" {
+			continue
+		}
+		if segments := parseSegments(parts[i]); len(segments) != 0 && segments[0].kind != staticSegment {
+			res[i].seg = &segments[0]
+		}
+	}
+	return res
+}
+
+// ParseParams reads the params of path out of the pattern,
+// a named catch-all gets the rest of the path without the leading slash.
+func (e *Entry[T, P]) ParseParams(path string) map[string]string {
+	var result = make(map[string]string)
+
+	var segments = e.segments
+	var parts = splitPath(path)
+
+	for i := 0; i < len(segments) && i < len(parts); i++ {
+		switch segments[i].kind {
+		case paramSegment:
+			result[segments[i].value] = parts[i]
+		case catchAllSegment:
+			if segments[i].value != "" {
+				result[segments[i].value] = strings.Join(parts[i:], "/")
+			}
+		}
+	}
+
+	return result
+}
+
+type tree[T any, P any] struct {
+	static   map[string]*tree[T, P]
+	params   []*tree[T, P]
+	catchAll *tree[T, P]
+	segment  segment
+	entry    *Entry[T, P]
+	// names is only kept by the root, for Router.URL
+	names map[string]*Entry[T, P]
+}
+
+func newTree[T any, P any]() *tree[T, P] {
+	return &tree[T, P]{}
+}
+
+func (t *tree[T, P]) insert(route string, data *Node[T, P]) {
+	var node = t
+	var segments = parseSegments(route)
+	for i := 0; i < len(segments); i++ {
+		node = node.child(segments[i], true)
+	}
+
+	if node.entry != nil {
+		panic(fmt.Sprintf("path %s is conflict with %s", route, node.entry.Path))
+	}
+
+	node.entry = &Entry[T, P]{Data: data, Path: route, segments: segments, url: parseURL(string(data.Route))}
+
+	if data.Name != "" {
+		if t.names == nil {
+			t.names = make(map[string]*Entry[T, P])
+		}
+		t.names[data.Name] = node.entry
+	}
+}
+
+func (t *tree[T, P]) remove(route string) {
+	var node = t
+	var segments = parseSegments(route)
+	for i := 0; i < len(segments) && node != nil; i++ {
+		node = node.child(segments[i], false)
+	}
+	if node == nil || node.entry == nil {
+		return
+	}
+	if name := node.entry.Data.Name; name != "" && t.names[name] == node.entry {
+		delete(t.names, name)
+	}
+	node.entry = nil
+}
+
+func (t *tree[T, P]) child(seg segment, create bool) *tree[T, P] {
+	switch seg.kind {
+	case paramSegment:
+		for i := 0; i < len(t.params); i++ {
+			if t.params[i].segment.constraint == seg.constraint {
+				return t.params[i]
+			}
+		}
+		if !create {
+			return nil
+		}
+		var node = &tree[T, P]{segment: seg}
+		// constrained params are tried first
+		var index = len(t.params)
+		if seg.constraint != "" {
+			for index = 0; index < len(t.params) && t.params[index].segment.constraint != ""; index++ {
+			}
+		}
+		t.params = append(t.params[:index], append([]*tree[T, P]{node}, t.params[index:]...)...)
+		return node
+	case catchAllSegment:
+		if t.catchAll == nil && create {
+			t.catchAll = &tree[T, P]{segment: seg}
+		}
+		return t.catchAll
+	default:
+		var node = t.static[seg.value]
+		if node == nil && create {
+			if t.static == nil {
+				t.static = make(map[string]*tree[T, P])
+			}
+			node = &tree[T, P]{segment: seg}
+			t.static[seg.value] = node
+		}
+		return node
+	}
+}
+
+// lookup prefers static segments, then params whose constraint accepts
+// the segment, then a catch-all, going back when a branch dead ends.
+// With fold, static segments are compared lower cased while params and
+// their constraints see the segment as sent.
+func (t *tree[T, P]) lookup(path string, fold bool) *Entry[T, P] {
+	return t.match(splitPath(path), fold)
+}
+
+func (t *tree[T, P]) match(parts []string, fold bool) *Entry[T, P] {
+	if len(parts) == 0 {
+		return t.entry
+	}
+
+	var key = parts[0]
+	if fold {
+		key = strings.ToLower(key)
+	}
+
+	if node := t.static[key]; node != nil {
+		if entry := node.match(parts[1:], fold); entry != nil {
+			return entry
+		}
+	}
+
+	for i := 0; i < len(t.params); i++ {
+		if !t.params[i].segment.accept(parts[0]) {
+			continue
+		}
+		if entry := t.params[i].match(parts[1:], fold); entry != nil {
+			return entry
+		}
+	}
+
+	if t.catchAll != nil {
+		return t.catchAll.entry
+	}
+
+	return nil
+}
+
+func (t *tree[T, P]) all(res []*Node[T, P]) []*Node[T, P] {
+	if t.entry != nil {
+		res = append(res, t.entry.Data)
+	}
+	for _, node := range t.static {
+		res = node.all(res)
+	}
+	for i := 0; i < len(t.params); i++ {
+		res = t.params[i].all(res)
+	}
+	if t.catchAll != nil {
+		res = t.catchAll.all(res)
+	}
+	return res
+}
@@ -0,0 +1,87 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 05:40
+**/
+
+package router
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// URL builds the path of the route named name, params are key value pairs
+// like "id", 1. Every :param must be given and match its constraint,
+// a catch-all may be left out and is called path when it has no name.
+func (r *Router[T, P]) URL(name string, params ...any) (string, error) {
+	if len(params)%2 != 0 {
+		return "", errors.Wrapf(errors.Invalid, "params of %s must be key value pairs", name)
+	}
+
+	var root = r.rlock()
+	var entry = root.named(name)
+	root.mux.RUnlock()
+
+	if entry == nil {
+		return "", errors.Wrap(errors.RouteNameNotFound, name)
+	}
+
+	var values = make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[fmt.Sprint(params[i])] = fmt.Sprint(params[i+1])
+	}
+
+	var parts = make([]string, 0, len(entry.url))
+	for i := 0; i < len(entry.url); i++ {
+		var seg = entry.url[i].seg
+		if seg == nil {
+			parts = append(parts, entry.url[i].text)
+			continue
+		}
+
+		switch seg.kind {
+		case paramSegment:
+			var value, ok = values[seg.value]
+			if !ok || value == "" {
+				return "", errors.Wrapf(errors.MissingParam, "%s of %s", seg.value, name)
+			}
+			if !seg.accept(value) {
+				return "", errors.Wrapf(errors.Invalid, "param %s of %s must match %s but got '%s'", seg.value, name, seg.constraint, value)
+			}
+			parts = append(parts, url.PathEscape(value))
+		case catchAllSegment:
+			var key = seg.value
+			if key == "" {
+				key = "path"
+			}
+			if value := strings.Trim(values[key], "/"); value != "" {
+				var rest = strings.Split(value, "/")
+				for j := 0; j < len(rest); j++ {
+					parts = append(parts, url.PathEscape(rest[j]))
+				}
+			}
+			if len(parts) == 1 && parts[0] == "" {
+				return "/", nil
+			}
+			return strings.Join(parts, "/"), nil
+		}
+	}
+
+	return strings.Join(parts, "/"), nil
+}
+
+// Event is URL for socket routers, where the route is the event.
+func (r *Router[T, P]) Event(name string, params ...any) (string, error) {
+	return r.URL(name, params...)
+}
+
+func (r *Router[T, P]) named(name string) *Entry[T, P] {
+	if r.trie == nil {
+		return nil
+	}
+	return r.trie.names[name]
+}
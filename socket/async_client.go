@@ -64,6 +64,9 @@ func (c *AsyncClient[T, P]) Emit(event string, data []byte) (*Stream[T], error)
 	case <-timeout:
 		return nil, errors.Timeout
 	case stream := <-ch:
+		if stream.code != 0 {
+			return nil, newReplyError(stream)
+		}
 		return stream, nil
 	}
 }
@@ -96,6 +99,9 @@ func (c *AsyncClient[T, P]) JsonEmit(event string, data any) (*Stream[T], error)
 	case <-timeout:
 		return nil, errors.Timeout
 	case stream := <-ch:
+		if stream.code != 0 {
+			return nil, newReplyError(stream)
+		}
 		return stream, nil
 	}
 }
@@ -128,6 +134,9 @@ func (c *AsyncClient[T, P]) ProtoBufEmit(event string, data proto.Message) (*Str
 	case <-timeout:
 		return nil, errors.Timeout
 	case stream := <-ch:
+		if stream.code != 0 {
+			return nil, newReplyError(stream)
+		}
 		return stream, nil
 	}
 }
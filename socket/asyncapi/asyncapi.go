@@ -0,0 +1,189 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 03:50
+**/
+
+package asyncapi
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/http"
+	"github.com/lemonyxk/kitty/socket/http/openapi"
+)
+
+const Version = "3.0.0"
+
+type Document struct {
+	AsyncAPI   string                `json:"asyncapi"`
+	Info       Info                  `json:"info"`
+	Servers    map[string]*Server    `json:"servers,omitempty"`
+	Channels   map[string]*Channel   `json:"channels"`
+	Operations map[string]*Operation `json:"operations"`
+	Components *Components           `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server tells where the events are sent, Protocol is tcp, ws or udp.
+type Server struct {
+	Host     string `json:"host"`
+	Protocol string `json:"protocol"`
+}
+
+// Channel addresses use {name} for the params of the route.
+type Channel struct {
+	Address    string                `json:"address"`
+	Messages   map[string]*Message   `json:"messages,omitempty"`
+	Parameters map[string]*Parameter `json:"parameters,omitempty"`
+}
+
+// Parameter describes a {name} of a channel address,
+// Description is the constraint of the param when it has one.
+type Parameter struct {
+	Description string `json:"description,omitempty"`
+}
+
+type Message struct {
+	Name    string       `json:"name"`
+	Payload *http.Schema `json:"payload,omitempty"`
+}
+
+type Operation struct {
+	Action      string `json:"action"`
+	Channel     *Ref   `json:"channel"`
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	Tags        []*Tag `json:"tags,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	Messages    []*Ref `json:"messages,omitempty"`
+	Reply       *Reply `json:"reply,omitempty"`
+}
+
+type Reply struct {
+	Channel  *Ref   `json:"channel"`
+	Messages []*Ref `json:"messages,omitempty"`
+}
+
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas map[string]*http.Schema `json:"schemas,omitempty"`
+}
+
+// New lists every event of r as a channel the server receives on.
+// Payloads come from the router.Doc attached with Route.Doc,
+// Request is what the client sends and Response is the reply.
+func New[T any, P any](r *router.Router[T, P], info Info, server *Server) *Document {
+	var doc = &Document{
+		AsyncAPI:   Version,
+		Info:       info,
+		Channels:   make(map[string]*Channel),
+		Operations: make(map[string]*Operation),
+	}
+
+	if server != nil {
+		doc.Servers = map[string]*Server{"default": server}
+	}
+
+	var schemas = http.NewSchemas("#/components/schemas/")
+
+	var nodes = r.GetAllRouters()
+	sort.Slice(nodes, func(i, j int) bool { return string(nodes[i].Route) < string(nodes[j].Route) })
+
+	for i := 0; i < len(nodes); i++ {
+		var node = nodes[i]
+		var event = string(node.Route)
+
+		var meta = node.Doc
+		if meta == nil {
+			meta = &router.Doc{}
+		}
+
+		var address, params = openapi.PathTemplate(event)
+		var id = channelID(address, doc.Channels)
+		var channel = &Channel{Address: address, Messages: make(map[string]*Message)}
+		for j := 0; j < len(params); j++ {
+			if channel.Parameters == nil {
+				channel.Parameters = make(map[string]*Parameter)
+			}
+			channel.Parameters[params[j].Name] = &Parameter{Description: params[j].Constraint}
+		}
+		var operation = &Operation{
+			Action:      "receive",
+			Channel:     &Ref{Ref: "#/channels/" + id},
+			Summary:     strings.Join(node.Desc[len(node.Tags):], " "),
+			Description: meta.Description,
+			Deprecated:  meta.Deprecated,
+		}
+
+		if meta.Summary != "" {
+			operation.Summary = meta.Summary
+		}
+
+		var tags = node.Tags
+		if len(meta.Tags) != 0 {
+			tags = meta.Tags
+		}
+		for j := 0; j < len(tags); j++ {
+			operation.Tags = append(operation.Tags, &Tag{Name: tags[j]})
+		}
+
+		channel.Messages["request"] = &Message{Name: event}
+		if meta.Request != nil {
+			channel.Messages["request"].Payload = schemas.Of(meta.Request)
+		}
+		operation.Messages = []*Ref{{Ref: "#/channels/" + id + "/messages/request"}}
+
+		if meta.Response != nil {
+			channel.Messages["reply"] = &Message{Name: event, Payload: schemas.Of(meta.Response)}
+			operation.Reply = &Reply{
+				Channel:  &Ref{Ref: "#/channels/" + id},
+				Messages: []*Ref{{Ref: "#/channels/" + id + "/messages/reply"}},
+			}
+		}
+
+		doc.Channels[id] = channel
+		doc.Operations[id] = operation
+	}
+
+	if len(schemas.Defs()) != 0 {
+		doc.Components = &Components{Schemas: schemas.Defs()}
+	}
+
+	return doc
+}
+
+// channelID makes the address usable as a key, /user/login is user.login
+// and /user/{id} is user.id.
+// When the key is taken, /user.login and /user/login say, a _2, _3...
+// suffix keeps it apart, events are sorted so ids stay the same across runs.
+func channelID(address string, channels map[string]*Channel) string {
+	var id = strings.NewReplacer("/", ".", "{", "", "}", "").Replace(strings.Trim(address, "/"))
+	if id == "" {
+		id = "root"
+	}
+	if _, ok := channels[id]; !ok {
+		return id
+	}
+	for i := 2; ; i++ {
+		var next = id + "_" + strconv.Itoa(i)
+		if _, ok := channels[next]; !ok {
+			return next
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 04:15
+**/
+
+package asyncapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/lemonyxk/kitty/socket/http"
+)
+
+// Event is one row of the catalogue.
+type Event struct {
+	Name        string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	Request     string
+	Reply       string
+}
+
+// Events flattens the document for people, payloads are indented json
+// with the top level $ref resolved.
+func (d *Document) Events() []*Event {
+	var ids = make([]string, 0, len(d.Operations))
+	for id := range d.Operations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var res []*Event
+	for i := 0; i < len(ids); i++ {
+		var operation = d.Operations[ids[i]]
+		var channel = d.Channels[ids[i]]
+		var event = &Event{
+			Name:        channel.Address,
+			Summary:     operation.Summary,
+			Description: operation.Description,
+			Deprecated:  operation.Deprecated,
+		}
+		for j := 0; j < len(operation.Tags); j++ {
+			event.Tags = append(event.Tags, operation.Tags[j].Name)
+		}
+		if m := channel.Messages["request"]; m != nil && m.Payload != nil {
+			event.Request = d.indent(m.Payload)
+		}
+		if m := channel.Messages["reply"]; m != nil && m.Payload != nil {
+			event.Reply = d.indent(m.Payload)
+		}
+		res = append(res, event)
+	}
+
+	return res
+}
+
+// Markdown renders the catalogue as markdown.
+func (d *Document) Markdown() string {
+	var builder strings.Builder
+
+	builder.WriteString("# " + d.Info.Title + " " + d.Info.Version + "\n\n")
+	if d.Info.Description != "" {
+		builder.WriteString(d.Info.Description + "\n\n")
+	}
+	if server := d.Servers["default"]; server != nil {
+		builder.WriteString("Transport: " + server.Protocol + " " + server.Host + "\n\n")
+	}
+
+	var events = d.Events()
+	for i := 0; i < len(events); i++ {
+		var event = events[i]
+		builder.WriteString("## " + event.Name + "\n\n")
+		if event.Deprecated {
+			builder.WriteString("**Deprecated**\n\n")
+		}
+		if event.Summary != "" {
+			builder.WriteString(event.Summary + "\n\n")
+		}
+		if event.Description != "" {
+			builder.WriteString(event.Description + "\n\n")
+		}
+		if len(event.Tags) != 0 {
+			builder.WriteString("Tags: " + strings.Join(event.Tags, ", ") + "\n\n")
+		}
+		if event.Request != "" {
+			builder.WriteString("Request:\n\n```json\n" + event.Request + "\n```\n\n")
+		}
+		if event.Reply != "" {
+			builder.WriteString("Reply:\n\n```json\n" + event.Reply + "\n```\n\n")
+		}
+	}
+
+	return builder.String()
+}
+
+var catalogue = template.Must(template.New("catalogue").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Info.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 960px; margin: auto; }
+pre { background: #f5f5f5; padding: 8px; overflow: auto; }
+.deprecated { text-decoration: line-through; }
+</style>
+</head>
+<body>
+<h1>{{.Info.Title}} {{.Info.Version}}</h1>
+{{with .Info.Description}}<p>{{.}}</p>{{end}}
+{{with .Server}}<p>Transport: {{.Protocol}} {{.Host}}</p>{{end}}
+{{range .Events}}
+<h2{{if .Deprecated}} class="deprecated"{{end}}>{{.Name}}</h2>
+{{with .Summary}}<p>{{.}}</p>{{end}}
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{with .Tags}}<p>Tags: {{range $i, $t := .}}{{if $i}}, {{end}}{{$t}}{{end}}</p>{{end}}
+{{with .Request}}<p>Request</p><pre>{{.}}</pre>{{end}}
+{{with .Reply}}<p>Reply</p><pre>{{.}}</pre>{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// HTML renders the catalogue as a standalone page.
+func (d *Document) HTML() string {
+	var buf bytes.Buffer
+	_ = catalogue.Execute(&buf, map[string]any{
+		"Info":   d.Info,
+		"Server": d.Servers["default"],
+		"Events": d.Events(),
+	})
+	return buf.String()
+}
+
+func (d *Document) indent(schema *http.Schema) string {
+	if d.Components != nil && strings.HasPrefix(schema.Ref, "#/components/schemas/") {
+		if def := d.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]; def != nil {
+			schema = def
+		}
+	}
+	var bts, _ = json.MarshalIndent(schema, "", "  ")
+	return string(bts)
+}
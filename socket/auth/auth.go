@@ -0,0 +1,143 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 06:10
+**/
+
+package auth
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// Identity is whoever sent the request.
+type Identity interface {
+	HasRole(role string) bool
+	HasPermission(permission string) bool
+}
+
+// User is a plain Identity.
+type User struct {
+	ID          string
+	Roles       []string
+	Permissions []string
+}
+
+func (u *User) HasRole(role string) bool {
+	return contains(u.Roles, role)
+}
+
+func (u *User) HasPermission(permission string) bool {
+	return contains(u.Permissions, permission)
+}
+
+// Rule is what a route requires, it is set with Group.Data or Route.Data,
+// routes inherit the rule of their group and Route.Data replaces it.
+// Any one of Roles and all of Permissions are needed,
+// an empty rule only needs somebody to be identified.
+type Rule struct {
+	Public      bool
+	Roles       []string
+	Permissions []string
+}
+
+// Holder lets a route Data of your own carry a rule.
+type Holder interface {
+	AuthRule() *Rule
+}
+
+var holderType = reflect.TypeOf((*Holder)(nil)).Elem()
+
+// RuleOf finds the rule in a route Data. The Data may be nil, a Rule,
+// a *Rule, or a Holder by value or by pointer receiver, so Router[T, any],
+// Router[T, Rule], Router[T, *Rule] and Router[T, YourData] all work.
+// With Router[T, Rule] a route without Data has the zero Rule, which
+// needs an identity. The rule is nil when the route has none, ok is false
+// when data is something else and cannot carry a rule at all.
+func RuleOf(data any) (rule *Rule, ok bool) {
+	switch data := data.(type) {
+	case nil:
+		return nil, true
+	case *Rule:
+		return data, true
+	case Rule:
+		return &data, true
+	case Holder:
+		return data.AuthRule(), true
+	}
+
+	// AuthRule with a pointer receiver on a value Data
+	var rv = reflect.ValueOf(data)
+	if rv.Kind() != reflect.Ptr && reflect.PointerTo(rv.Type()).Implements(holderType) {
+		var ptr = reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		return ptr.Interface().(Holder).AuthRule(), true
+	}
+
+	return nil, false
+}
+
+// Check returns errors.Unauthorized without an identity
+// and errors.Forbidden when the identity falls short of the rule.
+func (r *Rule) Check(id Identity) error {
+	if r == nil || r.Public {
+		return nil
+	}
+
+	if id == nil {
+		return errors.Unauthorized
+	}
+
+	if len(r.Roles) != 0 {
+		var ok = false
+		for i := 0; i < len(r.Roles); i++ {
+			if id.HasRole(r.Roles[i]) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return errors.Wrap(errors.Forbidden, "need role "+strings.Join(r.Roles, " or "))
+		}
+	}
+
+	for i := 0; i < len(r.Permissions); i++ {
+		if !id.HasPermission(r.Permissions[i]) {
+			return errors.Wrap(errors.Forbidden, "need permission "+r.Permissions[i])
+		}
+	}
+
+	return nil
+}
+
+type identityKey struct{}
+
+// WithIdentity stores id in the stream context.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity stored with WithIdentity.
+func FromContext(ctx context.Context) Identity {
+	if ctx == nil {
+		return nil
+	}
+	var id, _ = ctx.Value(identityKey{}).(Identity)
+	return id
+}
+
+func contains(list []string, s string) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
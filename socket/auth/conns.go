@@ -0,0 +1,33 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 06:25
+**/
+
+package auth
+
+import (
+	"github.com/lemonyxk/structure/map"
+)
+
+// Conns keeps the identity of socket connections by fd,
+// set it when the client logs in and delete it in OnClose.
+type Conns struct {
+	ids *hash.Hash[int64, Identity]
+}
+
+func NewConns() *Conns {
+	return &Conns{ids: hash.New[int64, Identity]()}
+}
+
+func (c *Conns) Set(fd int64, id Identity) {
+	c.ids.Set(fd, id)
+}
+
+func (c *Conns) Get(fd int64) Identity {
+	return c.ids.Get(fd)
+}
+
+func (c *Conns) Delete(fd int64) {
+	c.ids.Delete(fd)
+}
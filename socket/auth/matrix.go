@@ -0,0 +1,64 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 06:35
+**/
+
+package auth
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lemonyxk/kitty/router"
+)
+
+// Entry is one row of the route and permission matrix.
+type Entry struct {
+	Route       string   `json:"route"`
+	Method      []string `json:"method,omitempty"`
+	Public      bool     `json:"public"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Guarded is false for routes without a rule, nothing is checked for them
+	Guarded bool `json:"guarded"`
+}
+
+// Matrix lists the rule of every route of r, sorted by route.
+func Matrix[T any, P any](r *router.Router[T, P]) []*Entry {
+	var nodes = r.GetAllRouters()
+	sort.Slice(nodes, func(i, j int) bool { return string(nodes[i].Route) < string(nodes[j].Route) })
+
+	var res = make([]*Entry, 0, len(nodes))
+	for i := 0; i < len(nodes); i++ {
+		var entry = &Entry{Route: string(nodes[i].Route), Method: nodes[i].Method}
+		if rule, _ := RuleOf(nodes[i].Data); rule != nil {
+			entry.Guarded = true
+			entry.Public = rule.Public
+			entry.Roles = rule.Roles
+			entry.Permissions = rule.Permissions
+		}
+		res = append(res, entry)
+	}
+
+	return res
+}
+
+// WriteCSV writes the matrix with a header row, lists are joined with |.
+func WriteCSV(w io.Writer, entries []*Entry) error {
+	var writer = csv.NewWriter(w)
+	_ = writer.Write([]string{"route", "method", "guarded", "public", "roles", "permissions"})
+	for i := 0; i < len(entries); i++ {
+		var e = entries[i]
+		_ = writer.Write([]string{
+			e.Route, strings.Join(e.Method, "|"),
+			strconv.FormatBool(e.Guarded), strconv.FormatBool(e.Public),
+			strings.Join(e.Roles, "|"), strings.Join(e.Permissions, "|"),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
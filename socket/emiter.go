@@ -16,6 +16,7 @@ type Emitter[T Packer] interface {
 	JsonEmit(event string, data any) error
 	ProtoBufEmit(event string, data proto.Message) error
 	Emit(event string, data []byte) error
+	RespondWithError(err error) error
 
 	SetCode(code uint32)
 	Code() uint32
@@ -0,0 +1,49 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 06:50
+**/
+
+package http
+
+import (
+	"fmt"
+
+	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/auth"
+)
+
+// Authorize returns a Before hook checking the auth.Rule in the route Data
+// against the identity from identify, auth.FromContext of the stream when nil.
+// Routes without a rule pass, routes whose Data cannot carry one, see
+// auth.RuleOf, are refused. Failures are answered 401 or 403,
+// as a problem when the server has ProblemDetails on.
+func Authorize[T Packer](identify func(stream *Stream[T]) auth.Identity) router.Before[*Stream[T]] {
+	if identify == nil {
+		identify = func(stream *Stream[T]) auth.Identity { return auth.FromContext(stream.Context) }
+	}
+	return func(stream *Stream[T]) error {
+		var rule, ok = auth.RuleOf(stream.Meta)
+		if !ok {
+			// fail closed, the route data was meant for something else
+			return deny(stream, errors.Wrap(errors.Forbidden, fmt.Sprintf("route data %T carries no auth rule", stream.Meta)))
+		}
+		if rule == nil {
+			return nil
+		}
+		if err := rule.Check(identify(stream)); err != nil {
+			return deny(stream, errors.Wrap(err, stream.Request.URL.Path))
+		}
+		return nil
+	}
+}
+
+func deny[T Packer](stream *Stream[T], err error) error {
+	if stream.problems {
+		return stream.Sender.Problem(0, err)
+	}
+	var status, _ = errors.StatusOf(err)
+	stream.Response.WriteHeader(status)
+	return err
+}
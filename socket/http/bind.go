@@ -0,0 +1,240 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 23:40
+**/
+
+package http
+
+import (
+	"encoding"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Bind fills a new T from the stream and checks its validate rules.
+// The json body is decoded first, then fields tagged with
+// param, query, form or header take the first source that has a value.
+// *multipart.FileHeader and []*multipart.FileHeader fields are filled
+// from the files named by their form tag.
+// Conversion and validation failures are joined into one error wrapping
+// errors.Invalid, a conversion failure keeps what the parser said.
+func Bind[T any, P Packer](stream *Stream[P]) (*T, error) {
+	var t = new(T)
+
+	var rv = reflect.ValueOf(t).Elem()
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("must be struct")
+	}
+
+	stream.Parser.Query()
+	stream.Parser.Auto()
+	if err := stream.Parser.Error(); err != nil {
+		return nil, errors.Wrap(errors.Invalid, err)
+	}
+
+	if len(stream.Json.Bytes()) != 0 {
+		if err := stream.Json.Decode(t); err != nil {
+			return nil, errors.Wrap(errors.Invalid, err)
+		}
+	}
+
+	var errs []error
+	bindStruct(stream, rv, &errs)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, errors.Wrap(errors.Invalid, err)
+	}
+
+	return t, nil
+}
+
+func bindStruct[P Packer](stream *Stream[P], rv reflect.Value, errs *[]error) {
+	var rt = rv.Type()
+
+	for i := 0; i < rv.NumField(); i++ {
+		var field = rt.Field(i)
+		var value = rv.Field(i)
+
+		if !value.CanSet() {
+			continue
+		}
+
+		if field.Anonymous && value.Kind() == reflect.Struct && !hasBindTag(field) {
+			bindStruct(stream, value, errs)
+			continue
+		}
+
+		if err := bindField(stream, field, value); err != nil {
+			*errs = append(*errs, err)
+			continue
+		}
+
+		if err := validate(rv, field, value); err != nil {
+			*errs = append(*errs, err)
+			continue
+		}
+
+		if err := NewValidator[any]().format(value); err != nil {
+			*errs = append(*errs, err)
+		}
+	}
+}
+
+func hasBindTag(field reflect.StructField) bool {
+	for _, tag := range []string{"param", "query", "form", "header", "json"} {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func bindField[P Packer](stream *Stream[P], field reflect.StructField, value reflect.Value) error {
+	if value.Type() == fileHeaderType || value.Type() == reflect.SliceOf(fileHeaderType) {
+		var name = field.Tag.Get("form")
+		if name == "" {
+			return nil
+		}
+		var files = stream.File.All(name)
+		if len(files) == 0 {
+			return nil
+		}
+		if value.Kind() == reflect.Slice {
+			value.Set(reflect.ValueOf(files))
+		} else {
+			value.Set(reflect.ValueOf(files[0]))
+		}
+		return nil
+	}
+
+	var name, values = lookup(stream, field)
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err := setValue(value, values); err != nil {
+		return &InvalidError[string]{
+			Key:   name,
+			Type:  value.Type().String(),
+			Value: values[0],
+			Op:    "type",
+			err:   err,
+		}
+	}
+
+	return nil
+}
+
+func lookup[P Packer](stream *Stream[P], field reflect.StructField) (string, []string) {
+	if name := field.Tag.Get("param"); name != "" {
+		if v, ok := stream.Params[name]; ok {
+			return name, []string{v}
+		}
+	}
+
+	if name := field.Tag.Get("query"); name != "" {
+		if v := stream.Query.Values[name]; len(v) != 0 {
+			return name, v
+		}
+	}
+
+	if name := field.Tag.Get("form"); name != "" {
+		if v := stream.Form.Values[name]; len(v) != 0 {
+			return name, v
+		}
+	}
+
+	if name := field.Tag.Get("header"); name != "" {
+		if v := stream.Request.Header.Values(name); len(v) != 0 {
+			return name, v
+		}
+	}
+
+	return "", nil
+}
+
+func setValue(v reflect.Value, values []string) error {
+	switch {
+	case v.Kind() == reflect.Ptr:
+		var elem = reflect.New(v.Type().Elem())
+		if err := setValue(elem.Elem(), values); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		var slice = reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i := 0; i < len(values); i++ {
+			if err := setScalar(slice.Index(i), values[i]); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	default:
+		return setScalar(v, values[0])
+	}
+}
+
+func setScalar(v reflect.Value, s string) error {
+	// time.Time and friends
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.New("unsupported type " + v.Type().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 01:05
+**/
+
+package http
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var customRules = map[string]Rule{
+	"required_if": requiredIf,
+	"eqfield":     compareField(func(c int) bool { return c == 0 }),
+	"nefield":     compareField(func(c int) bool { return c != 0 }),
+	"gtfield":     compareField(func(c int) bool { return c > 0 }),
+	"gtefield":    compareField(func(c int) bool { return c >= 0 }),
+	"ltfield":     compareField(func(c int) bool { return c < 0 }),
+	"ltefield":    compareField(func(c int) bool { return c <= 0 }),
+}
+
+var customMux sync.RWMutex
+
+// RuleContext is what a rule sees of the field it checks.
+type RuleContext struct {
+	Key   string
+	Value reflect.Value
+	// Param is the text after : or = in the tag
+	Param string
+	// Parent is the struct holding the field
+	Parent reflect.Value
+}
+
+// Field returns the sibling field called name, invalid when there is none.
+// name is the key used in messages, the json name, or else the Go name.
+func (c *RuleContext) Field(name string) reflect.Value {
+	if c.Parent.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	var rt = c.Parent.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if fieldKey(rt.Field(i)) == name {
+			return indirect(c.Parent.Field(i))
+		}
+	}
+	return indirect(c.Parent.FieldByName(name))
+}
+
+// Rule reports whether the field is valid.
+type Rule func(ctx *RuleContext) bool
+
+// RegisterRule makes name usable in validate tags, as name or name:param.
+// It replaces a rule with the same name, the builtin ones included.
+// Register before the tags are first checked, unknown names are tag errors.
+func RegisterRule(name string, rule Rule) {
+	customMux.Lock()
+	defer customMux.Unlock()
+	customRules[name] = rule
+}
+
+func lookupRule(name string) Rule {
+	customMux.RLock()
+	defer customMux.RUnlock()
+	return customRules[name]
+}
+
+// requiredIf handles required_if=Field:value, a Field that does not
+// exist fails so a misspelled name does not turn the rule off.
+func requiredIf(ctx *RuleContext) bool {
+	var name, want, _ = strings.Cut(ctx.Param, ":")
+	var field = ctx.Field(name)
+	if !field.IsValid() {
+		return false
+	}
+	if actual, ok := scalarString(field); !ok || actual != want {
+		return true
+	}
+	return !ctx.Value.IsZero()
+}
+
+func compareField(ok func(c int) bool) Rule {
+	return func(ctx *RuleContext) bool {
+		var field = ctx.Field(ctx.Param)
+		if !field.IsValid() {
+			return false
+		}
+		var c, comparable = compareValues(indirect(ctx.Value), field)
+		if !comparable {
+			return false
+		}
+		return ok(c)
+	}
+}
+
+func compareValues(a, b reflect.Value) (int, bool) {
+	if a.Type() == timeType && b.Type() == timeType {
+		return a.Interface().(time.Time).Compare(b.Interface().(time.Time)), true
+	}
+
+	switch {
+	case isInt(a) && isInt(b):
+		return compare(a.Int(), b.Int()), true
+	case isUint(a) && isUint(b):
+		return compare(a.Uint(), b.Uint()), true
+	case isFloat(a) && isFloat(b):
+		return compare(a.Float(), b.Float()), true
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String()), true
+	case a.Type() == b.Type() && a.CanInterface() && b.CanInterface():
+		if reflect.DeepEqual(a.Interface(), b.Interface()) {
+			return 0, true
+		}
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+func compare[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isInt(v reflect.Value) bool {
+	return v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64
+}
+
+func isUint(v reflect.Value) bool {
+	return v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+}
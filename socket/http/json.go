@@ -115,3 +115,7 @@ func (j *Json) Decode(v any) error {
 func (j *Json) Validate(v any) error {
 	return NewValidator[any]().From(j.bts).Bind(v)
 }
+
+func (j *Json) ValidateAll(v any) error {
+	return NewValidator[any]().All().From(j.bts).Bind(v)
+}
@@ -0,0 +1,49 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 20:30
+**/
+
+package http
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty/header"
+	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
+)
+
+// RateLimit returns a Before hook that limits a route or group with policy.
+func RateLimit[T Packer](policy *ratelimit.Policy) router.Before[*Stream[T]] {
+	return func(stream *Stream[T]) error {
+		return Limit(stream, policy)
+	}
+}
+
+// Limit takes a token for stream from policy, when there is none it answers
+// 429 with Retry-After and returns errors.RateLimited.
+// Conn and IP scopes are both counted per client ip.
+func Limit[T Packer](stream *Stream[T], policy *ratelimit.Policy) error {
+	var key string
+	if policy.Scope != ratelimit.Global {
+		key = stream.ClientIP()
+	}
+
+	ok, wait := policy.Allow(key)
+	if ok {
+		return nil
+	}
+
+	var seconds = int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	stream.Response.Header().Set(header.RetryAfter, strconv.Itoa(seconds))
+	stream.Response.WriteHeader(errors.RateLimited.Status())
+
+	return errors.Wrap(errors.RateLimited, stream.Request.URL.Path)
+}
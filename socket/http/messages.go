@@ -0,0 +1,102 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 01:30
+**/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lemonyxk/kitty/kitty/header"
+)
+
+var messages = make(map[string]map[string]string)
+
+var messagesMux sync.RWMutex
+
+// RegisterMessages adds the templates of lang keyed by op, like "required".
+// A template may use {key}, {type}, {value} and {contract}.
+func RegisterMessages(lang string, templates map[string]string) {
+	lang = strings.ToLower(lang)
+
+	messagesMux.Lock()
+	defer messagesMux.Unlock()
+
+	if messages[lang] == nil {
+		messages[lang] = make(map[string]string)
+	}
+	for op, template := range templates {
+		messages[lang][op] = template
+	}
+}
+
+// Language picks the first language of the Accept-Language header
+// that has messages, "en-US" falls back to "en". Empty when none match.
+func Language(r *http.Request) string {
+	var accept = r.Header.Get(header.AcceptLanguage)
+	if accept == "" {
+		return ""
+	}
+
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var list []weighted
+	for _, part := range strings.Split(accept, ",") {
+		var lang, params, _ = strings.Cut(strings.TrimSpace(part), ";")
+		var q = 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, _ = strconv.ParseFloat(v, 64)
+		}
+		if lang != "" && q > 0 {
+			list = append(list, weighted{strings.ToLower(lang), q})
+		}
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return list[i].q > list[j].q })
+
+	messagesMux.RLock()
+	defer messagesMux.RUnlock()
+
+	for i := 0; i < len(list); i++ {
+		if messages[list[i].lang] != nil {
+			return list[i].lang
+		}
+		if base, _, ok := strings.Cut(list[i].lang, "-"); ok && messages[base] != nil {
+			return base
+		}
+	}
+
+	return ""
+}
+
+func render(lang string, op string, key string, typ string, value any, contract string) (string, bool) {
+	if lang == "" {
+		return "", false
+	}
+
+	messagesMux.RLock()
+	var template, ok = messages[strings.ToLower(lang)][op]
+	messagesMux.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	var actual = fmt.Sprint(value)
+	if actual == "" {
+		actual = "''"
+	}
+
+	var replacer = strings.NewReplacer("{key}", key, "{type}", typ, "{value}", actual, "{contract}", contract)
+
+	return replacer.Replace(template), true
+}
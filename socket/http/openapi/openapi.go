@@ -0,0 +1,290 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 03:00
+**/
+
+package openapi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lemonyxk/kitty/kitty/header"
+	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/http"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Tags       []*Tag               `json:"tags,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem is keyed by the lower case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string       `json:"name"`
+	In       string       `json:"in"`
+	Required bool         `json:"required,omitempty"`
+	Schema   *http.Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *http.Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*http.Schema `json:"schemas,omitempty"`
+}
+
+// New walks every route of r. Tags come from the group desc
+// and payloads from the router.Doc attached with Route.Doc.
+func New[T http.Packer, P any](r *router.Router[*http.Stream[T], P], info Info) *Document {
+	var doc = &Document{OpenAPI: Version, Info: info, Paths: make(map[string]*PathItem)}
+
+	var schemas = http.NewSchemas("#/components/schemas/")
+
+	var tags = make(map[string]bool)
+
+	var nodes = r.GetAllRouters()
+	sort.Slice(nodes, func(i, j int) bool { return string(nodes[i].Route) < string(nodes[j].Route) })
+
+	for i := 0; i < len(nodes); i++ {
+		var node = nodes[i]
+
+		var path, params = convertPath(string(node.Route))
+
+		var item = doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		for j := 0; j < len(node.Method); j++ {
+			var operation = newOperation(schemas, node, params)
+			(*item)[strings.ToLower(node.Method[j])] = operation
+			for k := 0; k < len(operation.Tags); k++ {
+				if !tags[operation.Tags[k]] {
+					tags[operation.Tags[k]] = true
+					doc.Tags = append(doc.Tags, &Tag{Name: operation.Tags[k]})
+				}
+			}
+		}
+	}
+
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	if len(schemas.Defs()) != 0 {
+		doc.Components = &Components{Schemas: schemas.Defs()}
+	}
+
+	return doc
+}
+
+// Handler serves the document of r as json. It is built again
+// whenever the routes of r change, so it follows Mount, Unmount and Replace.
+func Handler[T http.Packer, P any](r *router.Router[*http.Stream[T], P], info Info) func(stream *http.Stream[T]) error {
+	var mux sync.Mutex
+	var doc *Document
+	var generation uint64
+	return func(stream *http.Stream[T]) error {
+		mux.Lock()
+		if current := r.Generation(); doc == nil || generation != current {
+			doc, generation = New(r, info), current
+		}
+		var res = doc
+		mux.Unlock()
+		return stream.Sender.Json(res)
+	}
+}
+
+func newOperation[T any, P any](schemas *http.Schemas, node *router.Node[T, P], params []pathParam) *Operation {
+	var operation = &Operation{
+		Tags:      node.Tags,
+		Summary:   strings.Join(node.Desc[len(node.Tags):], " "),
+		Responses: map[string]*Response{"200": {Description: "OK"}},
+	}
+
+	var doc = node.Doc
+	if doc == nil {
+		doc = &router.Doc{}
+	}
+
+	if len(doc.Tags) != 0 {
+		operation.Tags = doc.Tags
+	}
+	if doc.Summary != "" {
+		operation.Summary = doc.Summary
+	}
+	operation.Description = doc.Description
+	operation.OperationID = doc.OperationID
+	operation.Deprecated = doc.Deprecated
+
+	var declared = make(map[string]bool)
+	if doc.Params != nil {
+		operation.Parameters = parameters(schemas, reflect.TypeOf(doc.Params))
+		for i := 0; i < len(operation.Parameters); i++ {
+			if operation.Parameters[i].In == "path" {
+				declared[operation.Parameters[i].Name] = true
+			}
+		}
+	}
+
+	for i := 0; i < len(params); i++ {
+		if !declared[params[i].name] {
+			operation.Parameters = append(operation.Parameters, &Parameter{
+				Name: params[i].name, In: "path", Required: true, Schema: params[i].schema,
+			})
+		}
+	}
+
+	if doc.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{header.ApplicationJson: {Schema: schemas.Of(doc.Request)}},
+		}
+	}
+
+	if doc.Response != nil {
+		operation.Responses["200"].Content = map[string]*MediaType{header.ApplicationJson: {Schema: schemas.Of(doc.Response)}}
+	}
+
+	return operation
+}
+
+func parameters(schemas *http.Schemas, rt reflect.Type) []*Parameter {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var res []*Parameter
+	for i := 0; i < rt.NumField(); i++ {
+		var field = rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			res = append(res, parameters(schemas, field.Type)...)
+			continue
+		}
+
+		for _, in := range [][2]string{{"param", "path"}, {"query", "query"}, {"header", "header"}} {
+			var name = field.Tag.Get(in[0])
+			if name == "" {
+				continue
+			}
+			var schema, required = schemas.Field(field)
+			res = append(res, &Parameter{Name: name, In: in[1], Required: required || in[1] == "path", Schema: schema})
+		}
+	}
+
+	return res
+}
+
+type pathParam struct {
+	name   string
+	schema *http.Schema
+}
+
+// PathParam is a param of a route template, Constraint is what the route
+// has in <> and is empty for catch-alls and params without one.
+type PathParam struct {
+	Name       string
+	Constraint string
+}
+
+// PathTemplate turns /user/:id<int> into /user/{id}, * becomes {path}.
+func PathTemplate(route string) (string, []PathParam) {
+	var params []PathParam
+	var segments = strings.Split(route, "/")
+	for i := 0; i < len(segments); i++ {
+		if segments[i] == "" {
+			continue
+		}
+		switch segments[i][0] {
+		case ':':
+			var name, constraint, _ = strings.Cut(segments[i][1:], "<")
+			params = append(params, PathParam{Name: name, Constraint: strings.TrimSuffix(constraint, ">")})
+			segments[i] = "{" + name + "}"
+		case '*':
+			var name = segments[i][1:]
+			if name == "" {
+				name = "path"
+			}
+			params = append(params, PathParam{Name: name})
+			segments[i] = "{" + name + "}"
+			segments = segments[:i+1]
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func convertPath(route string) (string, []pathParam) {
+	var path, params = PathTemplate(route)
+	var res = make([]pathParam, len(params))
+	for i := 0; i < len(params); i++ {
+		res[i] = pathParam{name: params[i].Name, schema: constraintSchema(params[i].Constraint)}
+	}
+	return path, res
+}
+
+func constraintSchema(constraint string) *http.Schema {
+	switch constraint {
+	case "":
+		return &http.Schema{Type: "string"}
+	case "int", "uint":
+		return &http.Schema{Type: "integer"}
+	case "float":
+		return &http.Schema{Type: "number"}
+	case "uuid":
+		return &http.Schema{Type: "string", Format: "uuid"}
+	case "alpha":
+		return &http.Schema{Type: "string", Pattern: "^[a-zA-Z]+$"}
+	default:
+		return &http.Schema{Type: "string", Pattern: "^(?:" + constraint + ")$"}
+	}
+}
@@ -0,0 +1,109 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 03:20
+**/
+
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	http2 "net/http"
+	"path"
+)
+
+//go:embed ui
+var ui embed.FS
+
+var page = template.Must(template.ParseFS(ui, "ui/index.html"))
+
+// Assets tells the page where to load Swagger UI from,
+// an Integrity is the subresource integrity hash of its file and is left out when empty.
+// Spec is the url of the document, openapi.json next to the page when empty.
+type Assets struct {
+	CSS          string
+	CSSIntegrity string
+	JS           string
+	JSIntegrity  string
+	Spec         string
+}
+
+// DefaultAssets pins the dist of the swagger-ui v5.17.14 tag on jsDelivr,
+// the hashes are of the files in that tag.
+var DefaultAssets = Assets{
+	CSS:          "https://cdn.jsdelivr.net/gh/swagger-api/swagger-ui@v5.17.14/dist/swagger-ui.css",
+	CSSIntegrity: "sha384-wxLW6kwyHktdDGr6Pv1zgm/VGJh99lfUbzSn6HNHBENZlCN7W602k9VkGdxuFvPn",
+	JS:           "https://cdn.jsdelivr.net/gh/swagger-api/swagger-ui@v5.17.14/dist/swagger-ui-bundle.js",
+	JSIntegrity:  "sha384-wmyclcVGX/WhUkdkATwhaK1X1JtiNrr2EoYJ+diV3vj4v6OC5yCeSu+yW13SYJep",
+	Spec:         "openapi.json",
+}
+
+// UI is a Swagger UI page for StaticRouter.SetStaticPath, loaded from DefaultAssets.
+// It loads openapi.json next to itself, so serve Handler under the same prefix:
+//
+//	staticRouter.SetStaticPath("/docs", "", openapi.UI())
+//	router.Get("/docs/openapi.json").Handler(openapi.Handler(router, info))
+func UI() http2.FileSystem {
+	return UIWith(DefaultAssets)
+}
+
+// UIWith is UI loading Swagger UI from assets, a mirror or a self hosted copy.
+func UIWith(assets Assets) http2.FileSystem {
+	if assets.Spec == "" {
+		assets.Spec = DefaultAssets.Spec
+	}
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, assets); err != nil {
+		panic(err)
+	}
+	var sub, _ = fs.Sub(ui, "ui")
+	return &uiFS{FileSystem: http2.FS(sub), index: buf.Bytes()}
+}
+
+// uiFS serves index.html rendered, the rest as embedded.
+type uiFS struct {
+	http2.FileSystem
+	index []byte
+}
+
+func (u *uiFS) Open(name string) (http2.File, error) {
+	var file, err = u.FileSystem.Open(name)
+	if err != nil || path.Clean("/"+name) != "/index.html" {
+		return file, err
+	}
+	return &indexFile{File: file, reader: bytes.NewReader(u.index), size: int64(len(u.index))}, nil
+}
+
+type indexFile struct {
+	http2.File
+	reader *bytes.Reader
+	size   int64
+}
+
+func (f *indexFile) Read(p []byte) (int, error) {
+	return f.reader.Read(p)
+}
+
+func (f *indexFile) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *indexFile) Stat() (fs.FileInfo, error) {
+	var info, err = f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &indexInfo{FileInfo: info, size: f.size}, nil
+}
+
+type indexInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i *indexInfo) Size() int64 {
+	return i.size
+}
@@ -0,0 +1,106 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 23:05
+**/
+
+package http
+
+import (
+	"net/http"
+
+	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/json"
+	"github.com/lemonyxk/kitty/kitty/header"
+)
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Status   int             `json:"status"`
+	Detail   string          `json:"detail,omitempty"`
+	Instance string          `json:"instance,omitempty"`
+	Errors   []*ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField is one validation failure of the errors extension member.
+type ProblemField struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Value    any    `json:"value"`
+	Contract string `json:"contract,omitempty"`
+	Op       string `json:"op"`
+	Detail   string `json:"detail"`
+}
+
+type problemField interface {
+	problemField(lang string) *ProblemField
+}
+
+type problemFields interface {
+	problemFields(lang string) []*ProblemField
+}
+
+// NewProblem describes err for r. When status is 0 it comes from err,
+// 400 when err holds InvalidErrors and 500 otherwise.
+// Server errors without a public message get no detail.
+func NewProblem(r *http.Request, status int, err error) *Problem {
+	var problem = &Problem{Type: "about:blank", Instance: r.URL.Path}
+
+	var lang = Language(r)
+
+	errors.Walk(err, func(err error) bool {
+		if f, ok := err.(problemField); ok {
+			problem.Errors = append(problem.Errors, f.problemField(lang))
+		}
+		if f, ok := err.(problemFields); ok {
+			problem.Errors = append(problem.Errors, f.problemFields(lang)...)
+		}
+		return false
+	})
+
+	if status == 0 {
+		if s, ok := errors.StatusOf(err); ok {
+			status = s
+		} else if len(problem.Errors) != 0 {
+			status = errors.Invalid.Status()
+		} else {
+			status = http.StatusInternalServerError
+		}
+	}
+
+	problem.Status = status
+	problem.Title = http.StatusText(status)
+
+	var coded *errors.CodeError
+	switch {
+	case len(problem.Errors) != 0:
+		problem.Detail = "validation failed"
+	case errors.As(err, &coded) || status < http.StatusInternalServerError:
+		problem.Detail = errors.MessageOf(err)
+	default:
+		// internal errors stay in OnError
+	}
+
+	return problem
+}
+
+// Problem writes err as application/problem+json, see NewProblem.
+// Like RespondWithError it returns err.
+func (s *Sender) Problem(status int, err error) error {
+	var problem = NewProblem(s.request, status, err)
+
+	bts, e := json.Marshal(problem)
+	if e != nil {
+		return e
+	}
+
+	s.response.Header().Set(header.ContentType, header.ApplicationProblemJson)
+	s.response.WriteHeader(problem.Status)
+	if _, e = s.response.Write(bts); e != nil {
+		return e
+	}
+
+	return err
+}
@@ -0,0 +1,211 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 00:20
+**/
+
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// checkRules runs the rules added on top of the numeric ones.
+// Format rules skip empty strings, required is the one to reject them.
+func checkRules(parent reflect.Value, key string, v reflect.Value, parse *Type) error {
+
+	if err := checkLength(key, v, parse); err != nil {
+		return err
+	}
+
+	if len(parse.OneOf) != 0 {
+		var actual, ok = scalarString(v)
+		if ok && !contains(parse.OneOf, actual) {
+			return invalid(key, v, strings.Join(parse.OneOf, " "), "oneof")
+		}
+	}
+
+	if v.Kind() == reflect.String && v.Len() != 0 {
+		var s = v.String()
+
+		if parse.Regex != nil && !parse.Regex.MatchString(s) {
+			return invalid(key, v, parse.Regex.String(), "regex")
+		}
+
+		if parse.Email {
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return invalid(key, v, "", "email")
+			}
+		}
+
+		if parse.URL {
+			if u, err := url.ParseRequestURI(s); err != nil || u.Scheme == "" || u.Host == "" {
+				return invalid(key, v, "", "url")
+			}
+		}
+
+		if parse.UUID && !uuidPattern.MatchString(s) {
+			return invalid(key, v, "", "uuid")
+		}
+
+		if parse.IP && net.ParseIP(s) == nil {
+			return invalid(key, v, "", "ip")
+		}
+
+		if parse.CIDR {
+			if _, _, err := net.ParseCIDR(s); err != nil {
+				return invalid(key, v, "", "cidr")
+			}
+		}
+
+		if parse.Datetime != "" {
+			if _, err := time.Parse(parse.Datetime, s); err != nil {
+				return invalid(key, v, parse.Datetime, "datetime")
+			}
+		}
+	}
+
+	if parse.Dive != nil {
+		switch v.Kind() {
+		case reflect.Array, reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				if err := check(parent, fmt.Sprintf("%s[%d]", key, i), indirect(v.Index(i)), parse.Dive); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			var keys = sortedKeys(v)
+			for i := 0; i < len(keys); i++ {
+				if err := check(parent, fmt.Sprintf("%s[%v]", key, keys[i]), indirect(v.MapIndex(keys[i])), parse.Dive); err != nil {
+					return err
+				}
+			}
+		default:
+
+		}
+	}
+
+	for i := 0; i < len(parse.Custom); i++ {
+		var rule = lookupRule(parse.Custom[i].Name)
+		if rule == nil {
+			// parseTag refuses unknown rules, fail closed anyway
+			return invalid(key, v, parse.Custom[i].Param, parse.Custom[i].Name)
+		}
+		var ctx = &RuleContext{Key: key, Value: v, Param: parse.Custom[i].Param, Parent: parent}
+		if !rule(ctx) {
+			return invalid(key, v, parse.Custom[i].Param, parse.Custom[i].Name)
+		}
+	}
+
+	return nil
+}
+
+func checkLength(key string, v reflect.Value, parse *Type) error {
+	if parse.Min == "" && parse.Max == "" && parse.Len == "" {
+		return nil
+	}
+
+	var length int
+	switch v.Kind() {
+	case reflect.String:
+		length = utf8.RuneCountInString(v.String())
+	case reflect.Array, reflect.Slice, reflect.Map:
+		length = v.Len()
+	default:
+		return nil
+	}
+
+	if parse.Min != "" {
+		var val, _ = strconv.Atoi(parse.Min)
+		if length < val {
+			return invalidLength(key, v, length, parse.Min, "min")
+		}
+	}
+
+	if parse.Max != "" {
+		var val, _ = strconv.Atoi(parse.Max)
+		if length > val {
+			return invalidLength(key, v, length, parse.Max, "max")
+		}
+	}
+
+	if parse.Len != "" {
+		var val, _ = strconv.Atoi(parse.Len)
+		if length != val {
+			return invalidLength(key, v, length, parse.Len, "len")
+		}
+	}
+
+	return nil
+}
+
+func invalidLength(key string, v reflect.Value, length int, contract string, op string) error {
+	if v.Kind() == reflect.String {
+		return invalid(key, v, contract, op)
+	}
+	return &InvalidError[int64]{
+		Key:      key,
+		Type:     v.Type().String(),
+		Value:    int64(length),
+		Contract: contract,
+		Op:       op,
+	}
+}
+
+func invalid(key string, v reflect.Value, contract string, op string) error {
+	var actual, ok = scalarString(v)
+	if !ok && v.IsValid() && v.CanInterface() {
+		actual = fmt.Sprint(v.Interface())
+	}
+	return &InvalidError[string]{
+		Key:      key,
+		Type:     v.Type().String(),
+		Value:    actual,
+		Contract: contract,
+		Op:       op,
+	}
+}
+
+func scalarString(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func contains(list []string, s string) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,364 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 02:10
+**/
+
+package http
+
+import (
+	"reflect"
+	"strconv"
+)
+
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema draft 2020-12 that validate tags can express.
+type Schema struct {
+	Schema string `json:"$schema,omitempty"`
+	Ref    string `json:"$ref,omitempty"`
+
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+	Const            any      `json:"const,omitempty"`
+
+	MinLength     *int `json:"minLength,omitempty"`
+	MaxLength     *int `json:"maxLength,omitempty"`
+	MinItems      *int `json:"minItems,omitempty"`
+	MaxItems      *int `json:"maxItems,omitempty"`
+	MinProperties *int `json:"minProperties,omitempty"`
+	MaxProperties *int `json:"maxProperties,omitempty"`
+
+	Enum    []any  `json:"enum,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Default any    `json:"default,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// NewSchema reflects over v the way Validator walks it.
+// Named structs other than the root one are put in $defs and referenced.
+func NewSchema(v any) *Schema {
+	var g = newSchemaGenerator("#/$defs/")
+
+	var rt = reflect.TypeOf(v)
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	var schema = &Schema{}
+	if rt != nil {
+		if rt.Kind() == reflect.Struct && rt != timeType {
+			// the root can be referenced by recursive fields
+			g.names[rt] = ""
+			g.fillStruct(schema, rt)
+		} else {
+			schema = g.schema(rt)
+		}
+	}
+
+	schema.Schema = SchemaDraft
+	if len(g.defs) != 0 {
+		schema.Defs = g.defs
+	}
+
+	return schema
+}
+
+// ServeSchema returns a handler writing the schema of v.
+func ServeSchema[T Packer](v any) func(stream *Stream[T]) error {
+	var schema = NewSchema(v)
+	return func(stream *Stream[T]) error {
+		return stream.Sender.Json(schema)
+	}
+}
+
+// Schemas generates many schemas sharing one set of definitions,
+// every named struct is referenced as prefix + name.
+type Schemas struct {
+	g *schemaGenerator
+}
+
+// NewSchemas starts a set, prefix is like "#/components/schemas/".
+func NewSchemas(prefix string) *Schemas {
+	return &Schemas{g: newSchemaGenerator(prefix)}
+}
+
+// Of returns the schema of v, a $ref when v is a named struct.
+func (s *Schemas) Of(v any) *Schema {
+	var rt = reflect.TypeOf(v)
+	if rt == nil {
+		return &Schema{}
+	}
+	return s.g.schema(rt)
+}
+
+// Field returns the schema of a struct field with its validate rules,
+// and whether the field is required.
+func (s *Schemas) Field(field reflect.StructField) (*Schema, bool) {
+	return s.g.field(field)
+}
+
+// Defs returns the definitions referenced so far.
+func (s *Schemas) Defs() map[string]*Schema {
+	return s.g.defs
+}
+
+type schemaGenerator struct {
+	prefix string
+	defs   map[string]*Schema
+	names  map[reflect.Type]string
+}
+
+func newSchemaGenerator(prefix string) *schemaGenerator {
+	return &schemaGenerator{prefix: prefix, defs: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+func (g *schemaGenerator) schema(rt reflect.Type) *Schema {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+
+	if rt == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch rt.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if rt.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "array", Items: g.schema(rt.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(rt.Elem())}
+	case reflect.Struct:
+		return g.ref(rt)
+	default:
+		// interface and friends accept anything
+		return &Schema{}
+	}
+}
+
+func (g *schemaGenerator) ref(rt reflect.Type) *Schema {
+	if name, ok := g.names[rt]; ok {
+		if name == "" {
+			return &Schema{Ref: "#"}
+		}
+		return &Schema{Ref: g.prefix + name}
+	}
+
+	if rt.Name() == "" {
+		var schema = &Schema{}
+		g.fillStruct(schema, rt)
+		return schema
+	}
+
+	var name = rt.Name()
+	for i := 2; g.defs[name] != nil; i++ {
+		name = rt.Name() + strconv.Itoa(i)
+	}
+
+	var schema = &Schema{}
+	g.names[rt] = name
+	g.defs[name] = schema
+	g.fillStruct(schema, rt)
+
+	return &Schema{Ref: g.prefix + name}
+}
+
+func (g *schemaGenerator) fillStruct(schema *Schema, rt reflect.Type) {
+	schema.Type = "object"
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]*Schema)
+	}
+
+	for i := 0; i < rt.NumField(); i++ {
+		var field = rt.Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		var tag = field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		var ft = field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if field.Anonymous && tag == "" && ft.Kind() == reflect.Struct {
+			g.fillStruct(schema, ft)
+			continue
+		}
+
+		var key = fieldKey(field)
+		var property, required = g.field(field)
+		if required {
+			schema.Required = append(schema.Required, key)
+		}
+
+		schema.Properties[key] = property
+	}
+}
+
+func (g *schemaGenerator) field(field reflect.StructField) (*Schema, bool) {
+	var schema = g.schema(field.Type)
+
+	var rules = field.Tag.Get("validate")
+	if rules == "" {
+		return schema, false
+	}
+
+	var parse, err = parseTag(splitTag(rules))
+	if err != nil {
+		return schema, false
+	}
+
+	return applyRules(schema, &parse), parse.Required
+}
+
+func applyRules(schema *Schema, parse *Type) *Schema {
+	if schema.Ref != "" && parse.Default == "" {
+		// a $ref points to a struct, only default applies to it
+		return schema
+	}
+
+	var number = func(s string) *float64 {
+		var f, err = strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil
+		}
+		return &f
+	}
+
+	var integer = func(s string) *int {
+		var i, err = strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		return &i
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if parse.Gte != "" {
+			schema.Minimum = number(parse.Gte)
+		}
+		if parse.Lte != "" {
+			schema.Maximum = number(parse.Lte)
+		}
+		if parse.Gt != "" {
+			schema.ExclusiveMinimum = number(parse.Gt)
+		}
+		if parse.Lt != "" {
+			schema.ExclusiveMaximum = number(parse.Lt)
+		}
+		if parse.Eq != "" {
+			if n := number(parse.Eq); n != nil {
+				schema.Const = *n
+			}
+		}
+	case "string":
+		if parse.Min != "" {
+			schema.MinLength = integer(parse.Min)
+		}
+		if parse.Max != "" {
+			schema.MaxLength = integer(parse.Max)
+		}
+		if parse.Len != "" {
+			schema.MinLength, schema.MaxLength = integer(parse.Len), integer(parse.Len)
+		}
+		if parse.Regex != nil {
+			schema.Pattern = parse.Regex.String()
+		}
+		switch {
+		case parse.Email:
+			schema.Format = "email"
+		case parse.URL:
+			schema.Format = "uri"
+		case parse.UUID:
+			schema.Format = "uuid"
+		}
+	case "array":
+		if parse.NonEmpty {
+			schema.MinItems = integer("1")
+		}
+		if parse.Min != "" {
+			schema.MinItems = integer(parse.Min)
+		}
+		if parse.Max != "" {
+			schema.MaxItems = integer(parse.Max)
+		}
+		if parse.Len != "" {
+			schema.MinItems, schema.MaxItems = integer(parse.Len), integer(parse.Len)
+		}
+		if parse.Dive != nil && schema.Items != nil {
+			schema.Items = applyRules(schema.Items, parse.Dive)
+		}
+	case "object":
+		if parse.NonEmpty {
+			schema.MinProperties = integer("1")
+		}
+		if parse.Min != "" {
+			schema.MinProperties = integer(parse.Min)
+		}
+		if parse.Max != "" {
+			schema.MaxProperties = integer(parse.Max)
+		}
+		if parse.Dive != nil && schema.AdditionalProperties != nil {
+			schema.AdditionalProperties = applyRules(schema.AdditionalProperties, parse.Dive)
+		}
+	}
+
+	if len(parse.OneOf) != 0 {
+		for i := 0; i < len(parse.OneOf); i++ {
+			schema.Enum = append(schema.Enum, typedValue(schema.Type, parse.OneOf[i]))
+		}
+	}
+
+	if parse.Default != "" {
+		schema.Default = typedValue(schema.Type, parse.Default)
+	}
+
+	return schema
+}
+
+func typedValue(typ string, s string) any {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
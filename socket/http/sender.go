@@ -16,6 +16,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/lemonyxk/kitty/errors"
 	"github.com/lemonyxk/kitty/kitty/header"
 	"google.golang.org/protobuf/proto"
 )
@@ -93,8 +94,16 @@ func (s *Sender) Write(r io.Reader) error {
 	return err
 }
 
+// RespondWithError writes the public message of err with code,
+// or when code is 0 with the status declared by err, 500 if none.
 func (s *Sender) RespondWithError(code int, err error) error {
-	var e = s.Respond(code, err.Error())
+	if code == 0 {
+		code = http.StatusInternalServerError
+		if status, ok := errors.StatusOf(err); ok {
+			code = status
+		}
+	}
+	var e = s.Respond(code, errors.MessageOf(err))
 	if e != nil {
 		return e
 	}
@@ -0,0 +1,154 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 07:10
+**/
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lemonyxk/kitty/kitty/header"
+	"github.com/lemonyxk/kitty/router"
+	http2 "github.com/lemonyxk/kitty/socket/http"
+)
+
+// Host is a virtual host with its own routers, every condition set must hold.
+// Pattern matches the request host without the port, a label :name captures
+// into stream.Params and a leading * matches any subdomains.
+// Header and Value match a request header, Version matches the media type
+// version of Accept, like 2 for application/vnd.x.v2+json or version=2.
+type Host[T any] struct {
+	Pattern string
+	Header  string
+	Value   string
+	Version string
+
+	Router       *router.Router[*http2.Stream[Conn], T]
+	StaticRouter *StaticRouter
+
+	labels []string
+}
+
+// AddHost adds a virtual host, the first one matching serves the request.
+// Requests matching none go to SetRouter and SetStaticRouter.
+func (s *Server[T]) AddHost(host *Host[T]) *Server[T] {
+	if host.Pattern != "" {
+		host.labels = strings.Split(strings.ToLower(host.Pattern), ".")
+	}
+	s.hosts = append(s.hosts, host)
+	return s
+}
+
+// selectHost returns the routers for r, and the params captured from its host.
+func (s *Server[T]) selectHost(r *http.Request) (*router.Router[*http2.Stream[Conn], T], *StaticRouter, map[string]string) {
+	if len(s.hosts) == 0 {
+		return s.router, s.staticRouter, nil
+	}
+
+	var name = hostName(r.Host)
+	for i := 0; i < len(s.hosts); i++ {
+		if params, ok := s.hosts[i].match(name, r); ok {
+			return s.hosts[i].Router, s.hosts[i].StaticRouter, params
+		}
+	}
+
+	return s.router, s.staticRouter, nil
+}
+
+func (h *Host[T]) match(name string, r *http.Request) (map[string]string, bool) {
+	if h.Header != "" && r.Header.Get(h.Header) != h.Value {
+		return nil, false
+	}
+
+	if h.Version != "" && acceptVersion(r.Header.Get(header.Accept)) != strings.TrimPrefix(h.Version, "v") {
+		return nil, false
+	}
+
+	if len(h.labels) == 0 {
+		return nil, true
+	}
+
+	var labels = strings.Split(name, ".")
+	var pattern = h.labels
+
+	if pattern[0] == "*" {
+		if len(labels) < len(pattern) {
+			return nil, false
+		}
+		pattern = pattern[1:]
+		labels = labels[len(labels)-len(pattern):]
+	}
+
+	if len(labels) != len(pattern) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i := 0; i < len(pattern); i++ {
+		if strings.HasPrefix(pattern[i], ":") {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[pattern[i][1:]] = labels[i]
+			continue
+		}
+		if pattern[i] != labels[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func hostName(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// acceptVersion finds the first version in accept, the version parameter
+// or the .vN suffix of a vendor media type.
+func acceptVersion(accept string) string {
+	var ranges = strings.Split(accept, ",")
+	for i := 0; i < len(ranges); i++ {
+		var parts = strings.Split(ranges[i], ";")
+
+		for j := 1; j < len(parts); j++ {
+			var key, value, _ = strings.Cut(strings.TrimSpace(parts[j]), "=")
+			if strings.EqualFold(key, "version") && value != "" {
+				return strings.TrimPrefix(strings.Trim(value, `"`), "v")
+			}
+		}
+
+		var _, sub, _ = strings.Cut(strings.TrimSpace(parts[0]), "/")
+		if !strings.HasPrefix(sub, "vnd.") {
+			continue
+		}
+
+		sub, _, _ = strings.Cut(sub, "+")
+		var index = strings.LastIndex(sub, ".v")
+		if index < 0 || !digits(sub[index+2:]) {
+			continue
+		}
+
+		return sub[index+2:]
+	}
+	return ""
+}
+
+func digits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-20 07:40
+**/
+
+package server
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	http2 "github.com/lemonyxk/kitty/socket/http"
+)
+
+// cleanPath resolves //, /./ and .. and keeps a trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	var clean = path.Clean(p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return clean
+}
+
+// trailingSlash returns the clean p with the trailing slash of the route pattern,
+// false when it is p already. Catch-all routes take both.
+// The tree skips empty segments, so //evil.com/ may match and must not
+// come back as a protocol relative location.
+func trailingSlash(p string, pattern string) (string, bool) {
+	var target = cleanPath(p)
+
+	if target != "/" && !strings.Contains(pattern, "*") {
+		var want = pattern != "/" && strings.HasSuffix(pattern, "/")
+		if want && !strings.HasSuffix(target, "/") {
+			target += "/"
+		}
+		if !want && strings.HasSuffix(target, "/") {
+			target = strings.TrimSuffix(target, "/")
+		}
+	}
+
+	return target, target != p
+}
+
+// redirect answers 301 to GET and HEAD, and 308 to the rest
+// so the method and body are kept.
+func redirect(stream *http2.Stream[Conn], target string) {
+	var code = http.StatusPermanentRedirect
+	if stream.Request.Method == http.MethodGet || stream.Request.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+	// never leave the host, browsers read /\ like //
+	if len(target) > 1 && (target[1] == '/' || target[1] == '\\') {
+		target = "/" + strings.TrimLeft(target, "/\\")
+	}
+	if stream.Request.URL.RawQuery != "" {
+		target += "?" + stream.Request.URL.RawQuery
+	}
+	http.Redirect(stream.Response, stream.Request, target, code)
+}
+
+// allowMethods lists the methods of a route, HEAD comes with GET
+// and OPTIONS is always answered.
+func allowMethods(method []string) string {
+	var res = append([]string{}, method...)
+	var get, head, options bool
+	for i := 0; i < len(method); i++ {
+		switch method[i] {
+		case http.MethodGet:
+			get = true
+		case http.MethodHead:
+			head = true
+		case http.MethodOptions:
+			options = true
+		}
+	}
+	if get && !head {
+		res = append(res, http.MethodHead)
+	}
+	if !options {
+		res = append(res, http.MethodOptions)
+	}
+	return strings.Join(res, ", ")
+}
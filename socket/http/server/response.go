@@ -0,0 +1,46 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 23:20
+**/
+
+package server
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// response remembers whether the handler has answered already,
+// so a problem is never written on top of a response.
+type response struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *response) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *response) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *response) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.written = true
+		f.Flush()
+	}
+}
+
+func (w *response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.written = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *response) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -3,15 +3,18 @@ package server
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
 	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty"
+	"github.com/lemonyxk/kitty/kitty/header"
 	"github.com/lemonyxk/kitty/router"
 	http2 "github.com/lemonyxk/kitty/socket/http"
+	"github.com/lemonyxk/kitty/socket/proxy"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
 )
 
 type Server[T any] struct {
@@ -25,6 +28,16 @@ type Server[T any] struct {
 	// TLS
 	TLSConfig *tls.Config
 
+	// PROXY protocol v1/v2, only parsed from ProxyProtocolTrusted peers,
+	// the list must not be empty when ProxyProtocol is on
+	ProxyProtocol        bool
+	ProxyProtocolTrusted []string
+	ProxyProtocolTimeout time.Duration
+
+	// peers allowed to set Forwarded, X-Forwarded-* and X-Real-* headers,
+	// nothing is trusted when empty
+	TrustedProxies []string
+
 	OnOpen    func(stream *http2.Stream[Conn])
 	OnMessage func(stream *http2.Stream[Conn])
 	OnClose   func(stream *http2.Stream[Conn])
@@ -38,11 +51,26 @@ type Server[T any] struct {
 	ReadHeaderTimeout time.Duration
 	MaxHeaderBytes    int
 
+	RateLimit *ratelimit.Policy
+	Logger    kitty.Logger
+
+	// answer 404, 405 and unanswered handler errors with application/problem+json
+	ProblemDetails bool
+
+	// redirect /users/ to /users, or back, to match the registered route
+	RedirectTrailingSlash bool
+	// redirect paths with //, /./ or .. to the clean path
+	RedirectCleanPath bool
+
+	logger       kitty.Logger
 	middle       []func(next Middle) Middle
 	router       *router.Router[*http2.Stream[Conn], T]
 	staticRouter *StaticRouter
+	hosts        []*Host[T]
 	netListen    net.Listener
 	server       *http.Server
+	proxyTrusted proxy.Trusted
+	trusted      proxy.Trusted
 }
 
 type Middle router.Middle[*http2.Stream[Conn]]
@@ -51,6 +79,34 @@ func (s *Server[T]) Ready() {
 	if s.Addr == "" {
 		panic("addr can not be empty")
 	}
+
+	if s.ProxyProtocol {
+		if len(s.ProxyProtocolTrusted) == 0 {
+			panic("proxy protocol needs ProxyProtocolTrusted")
+		}
+
+		var trusted, err = proxy.ParseTrusted(s.ProxyProtocolTrusted...)
+		if err != nil {
+			panic(err)
+		}
+		s.proxyTrusted = trusted
+
+		if s.ProxyProtocolTimeout == 0 {
+			s.ProxyProtocolTimeout = 3 * time.Second
+		}
+	}
+
+	trusted, err := proxy.ParseTrusted(s.TrustedProxies...)
+	if err != nil {
+		panic(err)
+	}
+	s.trusted = trusted
+
+	if s.Logger == nil {
+		s.Logger = kitty.NewLogger(nil)
+	}
+
+	s.logger = kitty.With(s.Logger, "server", s.Name, "network", "http")
 }
 
 func (s *Server[T]) LocalAddr() net.Addr {
@@ -61,32 +117,113 @@ func (s *Server[T]) Use(middle ...func(next Middle) Middle) {
 	s.middle = append(s.middle, middle...)
 }
 
-func (s *Server[T]) process(w http.ResponseWriter, r *http.Request) {
+func (s *Server[T]) process(w http.ResponseWriter, r *http.Request, router *router.Router[*http2.Stream[Conn], T], params map[string]string) {
+	var stream = s.newStream(w, r)
+	s.middleware(stream, func(stream *http2.Stream[Conn]) {
+		s.handler(stream, router, params)
+	})
+}
+
+func (s *Server[T]) newStream(w http.ResponseWriter, r *http.Request) *http2.Stream[Conn] {
+	if s.ProblemDetails {
+		w = &response{ResponseWriter: w}
+	}
 	var stream = http2.NewStream[Conn](&conn{}, w, r)
-	s.middleware(stream)
+	stream.SetTrustedProxies(s.trusted)
+	stream.SetProblemDetails(s.ProblemDetails)
+	stream.Logger = s.streamLogger(stream)
+	return stream
+}
+
+// notFound answers a request no host and no default router took.
+func (s *Server[T]) notFound(w http.ResponseWriter, r *http.Request) {
+	var stream = s.newStream(w, r)
+
+	if s.OnOpen != nil {
+		s.OnOpen(stream)
+	}
+
+	var err = errors.Wrap(errors.RouteNotFount, r.Host+r.URL.Path)
+	s.writeError(stream, err)
+	if s.OnError != nil {
+		s.OnError(stream, err)
+	}
+	if s.OnClose != nil {
+		s.OnClose(stream)
+	}
+}
+
+func (s *Server[T]) streamLogger(stream *http2.Stream[Conn]) kitty.Logger {
+	var logger = s.logger
+	// served without Start or Ready
+	if logger == nil {
+		logger = kitty.NewLogger(nil)
+	}
+	return kitty.With(logger,
+		"remote", stream.ClientIP(), "method", stream.Request.Method, "path", stream.Request.URL.Path,
+	)
+}
+
+// writeError answers err with its status, as a problem when ProblemDetails is on.
+func (s *Server[T]) writeError(stream *http2.Stream[Conn], err error) {
+	if s.ProblemDetails {
+		_ = stream.Sender.Problem(0, err)
+		return
+	}
+	status, _ := errors.StatusOf(err)
+	stream.Response.WriteHeader(status)
 }
 
-func (s *Server[T]) middleware(stream *http2.Stream[Conn]) {
-	var next Middle = s.handler
+// problem answers a handler error nobody has answered yet.
+func (s *Server[T]) problem(stream *http2.Stream[Conn], err error) {
+	if w, ok := stream.Response.(*response); ok && !w.written {
+		_ = stream.Sender.Problem(0, err)
+	}
+}
+
+func (s *Server[T]) middleware(stream *http2.Stream[Conn], handler Middle) {
+	var next = handler
 	for i := len(s.middle) - 1; i >= 0; i-- {
 		next = s.middle[i](next)
 	}
 	next(stream)
 }
 
-func (s *Server[T]) handler(stream *http2.Stream[Conn]) {
+func (s *Server[T]) handler(stream *http2.Stream[Conn], router *router.Router[*http2.Stream[Conn], T], hostParams map[string]string) {
 
 	if s.OnOpen != nil {
 		s.OnOpen(stream)
 	}
 
+	if s.RateLimit != nil {
+		if err := http2.Limit(stream, s.RateLimit); err != nil {
+			if s.OnError != nil {
+				s.OnError(stream, err)
+			}
+			if s.OnClose != nil {
+				s.OnClose(stream)
+			}
+			return
+		}
+	}
+
+	if s.RedirectCleanPath {
+		if clean := cleanPath(stream.Request.URL.Path); clean != stream.Request.URL.Path {
+			redirect(stream, clean)
+			if s.OnClose != nil {
+				s.OnClose(stream)
+			}
+			return
+		}
+	}
+
 	// Get the router
 	var method = strings.ToUpper(stream.Request.Method)
-	n, formatPath := s.router.GetRoute(stream.Request.URL.Path)
+	n, formatPath := router.Lookup(stream.Request.URL.Path)
 
 	if n == nil {
-		stream.Response.WriteHeader(http.StatusNotFound)
 		var err = errors.Wrap(errors.RouteNotFount, stream.Request.URL.Path)
+		s.writeError(stream, err)
 		if s.OnError != nil {
 			s.OnError(stream, err)
 		}
@@ -96,17 +233,46 @@ func (s *Server[T]) handler(stream *http2.Stream[Conn]) {
 		return
 	}
 
+	if s.RedirectTrailingSlash {
+		if target, ok := trailingSlash(stream.Request.URL.Path, n.Path); ok {
+			redirect(stream, target)
+			if s.OnClose != nil {
+				s.OnClose(stream)
+			}
+			return
+		}
+	}
+
 	var allowMethod = false
+	var allowGet = false
 	for i := 0; i < len(n.Data.Method); i++ {
 		if method == n.Data.Method[i] {
 			allowMethod = true
 			break
 		}
+		if n.Data.Method[i] == http.MethodGet {
+			allowGet = true
+		}
+	}
+
+	// GET handlers answer HEAD, net/http drops the body and keeps its Content-Length
+	if !allowMethod && method == http.MethodHead && allowGet {
+		allowMethod = true
+	}
+
+	if !allowMethod && method == http.MethodOptions {
+		stream.Response.Header().Set(header.Allow, allowMethods(n.Data.Method))
+		stream.Response.WriteHeader(http.StatusNoContent)
+		if s.OnClose != nil {
+			s.OnClose(stream)
+		}
+		return
 	}
 
 	if !allowMethod {
-		stream.Response.WriteHeader(http.StatusMethodNotAllowed)
+		stream.Response.Header().Set(header.Allow, allowMethods(n.Data.Method))
 		var err = errors.Wrap(errors.MethodNotAllowed, stream.Request.URL.Path)
+		s.writeError(stream, err)
 		if s.OnError != nil {
 			s.OnError(stream, err)
 		}
@@ -117,8 +283,13 @@ func (s *Server[T]) handler(stream *http2.Stream[Conn]) {
 	}
 
 	stream.Params = n.ParseParams(formatPath)
+	for k, v := range hostParams {
+		if _, ok := stream.Params[k]; !ok {
+			stream.Params[k] = v
+		}
+	}
 
-	//stream.Node = n.Data
+	stream.Meta = n.Data.Data
 
 	var nodeData = n.Data
 
@@ -131,6 +302,7 @@ func (s *Server[T]) handler(stream *http2.Stream[Conn]) {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
+			s.problem(stream, err)
 			if s.OnError != nil {
 				s.OnError(stream, err)
 			}
@@ -146,6 +318,7 @@ func (s *Server[T]) handler(stream *http2.Stream[Conn]) {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
+			s.problem(stream, err)
 			if s.OnError != nil {
 				s.OnError(stream, err)
 			}
@@ -161,6 +334,7 @@ func (s *Server[T]) handler(stream *http2.Stream[Conn]) {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
+			s.problem(stream, err)
 			if s.OnError != nil {
 				s.OnError(stream, err)
 			}
@@ -209,6 +383,10 @@ func (s *Server[T]) Start() {
 		panic(err)
 	}
 
+	if s.ProxyProtocol {
+		netListen = proxy.NewListener(netListen, s.proxyTrusted, s.ProxyProtocolTimeout)
+	}
+
 	s.netListen = netListen
 	s.server = &server
 
@@ -225,7 +403,7 @@ func (s *Server[T]) Start() {
 	}
 
 	if err != nil {
-		fmt.Println(err)
+		s.logger.Error(err)
 	}
 }
 
@@ -242,17 +420,19 @@ func (s *Server[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var router, staticRouter, params = s.selectHost(r)
+
 	// static file
-	if s.staticRouter != nil && s.staticRouter.IsAllowMethod(r.Method) {
-		if s.staticHandler(w, r) == nil {
+	if staticRouter != nil && staticRouter.IsAllowMethod(r.Method) {
+		if s.staticHandler(staticRouter, w, r) == nil {
 			return
 		}
 	}
 
-	if s.router != nil {
-		s.process(w, r)
+	if router != nil {
+		s.process(w, r, router, params)
 		return
 	}
 
-	return
+	s.notFound(w, r)
 }
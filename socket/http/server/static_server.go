@@ -24,7 +24,7 @@ import (
 	"github.com/lemonyxk/kitty/kitty/header"
 )
 
-func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error {
+func (s *Server[T]) staticHandler(staticRouter *StaticRouter, w http.ResponseWriter, r *http.Request) error {
 
 	var static *Static
 
@@ -36,21 +36,21 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 
 	var urlPath string
 
-	for i := 0; i < len(s.staticRouter.static); i++ {
-		if !strings.HasPrefix(r.URL.Path, s.staticRouter.static[i].prefixPath) {
+	for i := 0; i < len(staticRouter.static); i++ {
+		if !strings.HasPrefix(r.URL.Path, staticRouter.static[i].prefixPath) {
 			continue
 		}
 
-		urlPath = r.URL.Path[len(s.staticRouter.static[i].prefixPath):]
+		urlPath = r.URL.Path[len(staticRouter.static[i].prefixPath):]
 
-		openPath = filepath.Join(s.staticRouter.static[i].fixPath, urlPath)
+		openPath = filepath.Join(staticRouter.static[i].fixPath, urlPath)
 
-		file, err = s.staticRouter.static[i].fileSystem.Open(openPath)
+		file, err = staticRouter.static[i].fileSystem.Open(openPath)
 		if err != nil {
 			continue
 		}
 
-		static = s.staticRouter.static[i]
+		static = staticRouter.static[i]
 
 		break
 	}
@@ -74,12 +74,12 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 
 		var findDefault = false
 
-		for i := 0; i < len(s.staticRouter.defaultIndex); i++ {
-			if s.staticRouter.defaultIndex[i] == "" {
+		for i := 0; i < len(staticRouter.defaultIndex); i++ {
+			if staticRouter.defaultIndex[i] == "" {
 				continue
 			}
 
-			var otp = filepath.Join(openPath, s.staticRouter.defaultIndex[i])
+			var otp = filepath.Join(openPath, staticRouter.defaultIndex[i])
 			var of, err = static.fileSystem.Open(otp)
 			if err != nil {
 				continue
@@ -101,14 +101,14 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 
 		if !findDefault {
 
-			if len(s.staticRouter.openDir) == 0 {
+			if len(staticRouter.openDir) == 0 {
 				w.WriteHeader(http.StatusForbidden)
 				return nil
 			}
 
 			var shouldOpen = false
-			for i := 0; i < len(s.staticRouter.openDir); i++ {
-				if s.staticRouter.openDir[i] == static.index {
+			for i := 0; i < len(staticRouter.openDir); i++ {
+				if staticRouter.openDir[i] == static.index {
 					shouldOpen = true
 					break
 				}
@@ -119,7 +119,7 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 				return nil
 			}
 
-			var fn, ok = s.staticRouter.staticDirMiddle[urlPath]
+			var fn, ok = staticRouter.staticDirMiddle[urlPath]
 			if ok {
 				var err = fn(w, r, file, info)
 				if err != nil {
@@ -129,8 +129,8 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 				return nil
 			}
 
-			if s.staticRouter.staticGlobalDirMiddle != nil {
-				var err = s.staticRouter.staticGlobalDirMiddle(w, r, file, info)
+			if staticRouter.staticGlobalDirMiddle != nil {
+				var err = staticRouter.staticGlobalDirMiddle(w, r, file, info)
 				if err != nil {
 					w.WriteHeader(http.StatusForbidden)
 					return nil
@@ -138,13 +138,13 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 				return nil
 			}
 
-			return s.staticDefaultDirMiddle(w, r, file)
+			return s.staticDefaultDirMiddle(staticRouter, w, r, file)
 		}
 	}
 
 	var ext = filepath.Ext(info.Name())
 
-	var fn, ok = s.staticRouter.staticFileMiddle[ext]
+	var fn, ok = staticRouter.staticFileMiddle[ext]
 	if ok {
 		var err = fn(w, r, file, info)
 		if err != nil {
@@ -154,8 +154,8 @@ func (s *Server[T]) staticHandler(w http.ResponseWriter, r *http.Request) error
 		return nil
 	}
 
-	if s.staticRouter.staticGlobalFileMiddle != nil {
-		var err = s.staticRouter.staticGlobalFileMiddle(w, r, file, info)
+	if staticRouter.staticGlobalFileMiddle != nil {
+		var err = staticRouter.staticGlobalFileMiddle(w, r, file, info)
 		if err != nil {
 			w.WriteHeader(http.StatusForbidden)
 			return nil
@@ -183,7 +183,7 @@ func (s *Server[T]) staticDefaultFileMiddle(w http.ResponseWriter, err error, fi
 	return nil
 }
 
-func (s *Server[T]) staticDefaultDirMiddle(w http.ResponseWriter, r *http.Request, file http.File) error {
+func (s *Server[T]) staticDefaultDirMiddle(staticRouter *StaticRouter, w http.ResponseWriter, r *http.Request, file http.File) error {
 	dir, err := file.Readdir(-1)
 	if err != nil {
 		return nil
@@ -217,7 +217,7 @@ func (s *Server[T]) staticDefaultDirMiddle(w http.ResponseWriter, r *http.Reques
 		bts.WriteString(`<a href="` + p + `">` + name + `</a>` + strings.Repeat(" ", l))
 		bts.WriteString(" " + dir[i].ModTime().Format("02-Jan-2006 15:04") + strings.Repeat(" ", 20-len(size)) + size)
 
-		if s.staticRouter.staticDownload && !dir[i].IsDir() {
+		if staticRouter.staticDownload && !dir[i].IsDir() {
 			bts.WriteString("  " + `<a download href="` + filepath.Join(r.URL.Path, dir[i].Name()) + `">` + "download" + `</a>`)
 		}
 
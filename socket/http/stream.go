@@ -2,7 +2,6 @@ package http
 
 import (
 	"bytes"
-	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,13 +10,12 @@ import (
 	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/kitty/header"
 	"github.com/lemonyxk/kitty/socket"
+	"github.com/lemonyxk/kitty/socket/proxy"
 )
 
 type Stream[T Packer] struct {
 	sender[T]
 
-	//Node *router.Node[*Stream[T]]
-
 	Time time.Time
 
 	Response http.ResponseWriter
@@ -34,9 +32,15 @@ type Stream[T Packer] struct {
 	Context kitty.Context
 	Logger  kitty.Logger
 
+	// Meta is the Data of the matched route
+	Meta any
+
 	Sender *Sender
 
 	Parser *Parser[T]
+
+	trusted  proxy.Trusted
+	problems bool
 }
 
 func NewStream[T Packer](conn T, w http.ResponseWriter, r *http.Request) *Stream[T] {
@@ -67,30 +71,24 @@ func (s *Stream[T]) SetHeader(header string, content string) {
 	s.Response.Header().Set(header, content)
 }
 
+// SetTrustedProxies sets the peers whose forwarding headers are believed
+// by Host, ClientIP and Scheme.
+func (s *Stream[T]) SetTrustedProxies(trusted proxy.Trusted) {
+	s.trusted = trusted
+}
+
+// SetProblemDetails tells hooks answering on their own, like Authorize,
+// to write problems instead of a bare status.
+func (s *Stream[T]) SetProblemDetails(on bool) {
+	s.problems = on
+}
+
 func (s *Stream[T]) Host() string {
-	if host := strings.Split(s.Request.Header.Get(header.XForwardedHost), ",")[0]; host != "" {
-		return host
-	}
-	if host := s.Request.Header.Get(header.XRealHost); host != "" {
-		return host
-	}
-	if host := s.Request.Header.Get(header.Host); host != "" {
-		return host
-	}
-	return s.Request.Host
+	return proxy.Host(s.Request, s.trusted)
 }
 
 func (s *Stream[T]) ClientIP() string {
-	if ip := strings.Split(s.Request.Header.Get(header.XForwardedFor), ",")[0]; ip != "" {
-		return ip
-	}
-	if ip := s.Request.Header.Get(header.XRealIP); ip != "" {
-		return ip
-	}
-	if ip, _, err := net.SplitHostPort(s.Request.RemoteAddr); err == nil {
-		return ip
-	}
-	return ""
+	return proxy.ClientIP(s.Request, s.trusted)
 }
 
 //func (s *Stream[T]) Has(key string) bool {
@@ -305,11 +303,7 @@ func (s *Stream[T]) String() string {
 }
 
 func (s *Stream[T]) Scheme() string {
-	var scheme = "http"
-	if s.Request.TLS != nil {
-		scheme = "https"
-	}
-	return scheme
+	return proxy.Scheme(s.Request, s.trusted)
 }
 
 func (s *Stream[T]) UpgradeSse(config *SseConfig) (*Sse[T], error) {
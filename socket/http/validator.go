@@ -12,10 +12,13 @@ package http
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/lemonyxk/kitty/errors"
 	"github.com/lemonyxk/kitty/json"
 	"io"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -31,6 +34,9 @@ type InvalidError[T any] struct {
 	Value    T      `json:"value"`
 	Contract string `json:"contract"`
 	Op       string `json:"op"`
+
+	// err is why the value could not be converted, for the type op
+	err error
 }
 
 func (i *InvalidError[T]) Builder() *bytes.Buffer {
@@ -65,6 +71,74 @@ func (i *InvalidError[T]) Builder() *bytes.Buffer {
 		builder.WriteString(" = ")
 		builder.WriteString(i.Contract)
 		builder.WriteString(" but got ")
+	case "type":
+		builder.WriteString(" must be ")
+		builder.WriteString(i.Type)
+		builder.WriteString(" but got ")
+	case "min":
+		builder.WriteString(" length >= ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "max":
+		builder.WriteString(" length <= ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "len":
+		builder.WriteString(" length = ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "oneof":
+		builder.WriteString(" must be one of ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "regex":
+		builder.WriteString(" must match ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "datetime":
+		builder.WriteString(" must be a datetime like ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "email", "url", "uuid", "ip", "cidr":
+		builder.WriteString(" must be a valid ")
+		builder.WriteString(i.Op)
+		builder.WriteString(" but got ")
+	case "required_if":
+		builder.WriteString(" is required when ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "eqfield":
+		builder.WriteString(" must equal ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "nefield":
+		builder.WriteString(" must not equal ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "gtfield":
+		builder.WriteString(" > ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "gtefield":
+		builder.WriteString(" >= ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "ltfield":
+		builder.WriteString(" < ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	case "ltefield":
+		builder.WriteString(" <= ")
+		builder.WriteString(i.Contract)
+		builder.WriteString(" but got ")
+	default:
+		builder.WriteString(" must pass ")
+		builder.WriteString(i.Op)
+		if i.Contract != "" {
+			builder.WriteString(" ")
+			builder.WriteString(i.Contract)
+		}
+		builder.WriteString(" but got ")
 	}
 
 	var k = reflect.ValueOf(i.Value)
@@ -98,55 +172,86 @@ func (i *InvalidError[T]) String() string {
 }
 
 func (i *InvalidError[T]) MarshalJSON() ([]byte, error) {
-	return i.Builder().Bytes(), nil
-}
-
-//
-//func (i *InvalidError[T]) Message() string {
-//	var builder strings.Builder
-//	builder.WriteString("key: ")
-//	builder.WriteString(i.Key)
-//	builder.WriteString(", type: ")
-//	builder.WriteString(i.Type)
-//
-//	var k = reflect.ValueOf(i.Value)
-//
-//	builder.WriteString(", value: ")
-//	switch k.Kind() {
-//	case reflect.Bool:
-//		builder.WriteString(strconv.FormatBool(k.Bool()))
-//	case reflect.String:
-//		builder.WriteString(k.String())
-//	case reflect.Int64:
-//		builder.WriteString(strconv.FormatInt(k.Int(), 10))
-//	case reflect.Uint64:
-//		builder.WriteString(strconv.FormatUint(k.Uint(), 10))
-//	case reflect.Float64:
-//		builder.WriteString(strconv.FormatFloat(k.Float(), 'f', -1, 64))
-//	default:
-//		builder.WriteString("unknown")
-//	}
-//
-//	if i.Contract != "" {
-//		builder.WriteString(", contract: ")
-//		builder.WriteString(i.Contract)
-//	}
-//
-//	builder.WriteString(", op: ")
-//	builder.WriteString(i.Op)
-//
-//	return builder.String()
-//}
+	return json.Marshal(i.String())
+}
+
+func (i *InvalidError[T]) problemField(lang string) *ProblemField {
+	return &ProblemField{Key: i.Key, Type: i.Type, Value: i.Value, Contract: i.Contract, Op: i.Op, Detail: i.Message(lang)}
+}
+
+// Message renders the template registered for lang, or String when there is none.
+func (i *InvalidError[T]) Message(lang string) string {
+	if message, ok := render(lang, i.Op, i.Key, i.Type, i.Value, i.Contract); ok {
+		return message
+	}
+	return i.String()
+}
 
 func (i *InvalidError[T]) Error() string {
+	if i.err != nil {
+		return i.String() + ": " + i.err.Error()
+	}
 	return i.String()
 }
 
+func (i *InvalidError[T]) Unwrap() error {
+	return i.err
+}
+
+// ValidationError is one failure found by an All validator.
+type ValidationError struct {
+	Path     string `json:"path"`
+	Op       string `json:"op"`
+	Contract string `json:"contract,omitempty"`
+	Type     string `json:"type"`
+	Value    any    `json:"value"`
+	Message  string `json:"message"`
+}
+
+// ValidationErrors keeps the failures in the order the fields were walked.
+type ValidationErrors []*ValidationError
+
+func (v ValidationErrors) Error() string {
+	var builder strings.Builder
+	for i := 0; i < len(v); i++ {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(v[i].Path)
+		builder.WriteString(": ")
+		builder.WriteString(v[i].Message)
+	}
+	return builder.String()
+}
+
+// Localize renders the template registered for lang, or Message when there is none.
+func (v *ValidationError) Localize(lang string) string {
+	if message, ok := render(lang, v.Op, v.Path, v.Type, v.Value, v.Contract); ok {
+		return message
+	}
+	return v.Message
+}
+
+func (v ValidationErrors) problemFields(lang string) []*ProblemField {
+	var res = make([]*ProblemField, len(v))
+	for i := 0; i < len(v); i++ {
+		res[i] = &ProblemField{
+			Key: v[i].Path, Type: v[i].Type, Value: v[i].Value,
+			Contract: v[i].Contract, Op: v[i].Op, Detail: v[i].Localize(lang),
+		}
+	}
+	return res
+}
+
 type Validator[T any] struct {
 	visited map[uintptr]bool
 	deep    int
 	err     error
 
+	all  bool
+	path string
+	errs ValidationErrors
+
 	bts []byte
 }
 
@@ -154,6 +259,13 @@ func NewValidator[T any]() *Validator[T] {
 	return &Validator[T]{visited: make(map[uintptr]bool), deep: 0}
 }
 
+// All makes Bind walk the whole value instead of stopping at the first failure,
+// the failures come back as ValidationErrors.
+func (v *Validator[T]) All() *Validator[T] {
+	v.all = true
+	return v
+}
+
 func (v *Validator[T]) From(bts []byte) *Validator[T] {
 	v.bts = bts
 	return v
@@ -185,12 +297,40 @@ func (v *Validator[T]) Bind(t T) error {
 		return err
 	}
 
+	var errs = v.errs
+
 	v.visited = make(map[uintptr]bool)
 	v.deep = 0
+	v.path = ""
+	v.errs = nil
+
+	if len(errs) != 0 {
+		return errs
+	}
 
 	return nil
 }
 
+func (v *Validator[T]) collect(err error) {
+	var e = &ValidationError{Path: v.path, Message: err.Error()}
+	if f, ok := err.(problemField); ok {
+		var field = f.problemField("")
+		e.Path = joinPath(v.path, field.Key)
+		e.Op = field.Op
+		e.Contract = field.Contract
+		e.Type = field.Type
+		e.Value = field.Value
+	}
+	v.errs = append(v.errs, e)
+}
+
+func joinPath(path string, key string) string {
+	if path == "" || strings.HasPrefix(key, "[") {
+		return path + key
+	}
+	return path + "." + key
+}
+
 func (v *Validator[T]) do(r any) error {
 
 	var rv = reflect.ValueOf(r)
@@ -285,10 +425,14 @@ func (v *Validator[T]) printMap(rv reflect.Value) error {
 
 	v.visited[rv.Pointer()] = true
 
-	keys := rv.MapKeys()
-	for i := 0; i < rv.Len(); i++ {
+	var path = v.path
+
+	keys := sortedKeys(rv)
+	for i := 0; i < len(keys); i++ {
 		value := rv.MapIndex(keys[i])
+		v.path = fmt.Sprintf("%s[%v]", path, keys[i])
 		var err = v.format(value)
+		v.path = path
 		if err != nil {
 			return err
 		}
@@ -299,6 +443,28 @@ func (v *Validator[T]) printMap(rv reflect.Value) error {
 	return nil
 }
 
+// sortedKeys orders the keys of a map so the first failure reported is
+// the same on every run, numbers by value and anything else by its text.
+func sortedKeys(rv reflect.Value) []reflect.Value {
+	var keys = rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		var a, b = keys[i], keys[j]
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		default:
+			return fmt.Sprint(a) < fmt.Sprint(b)
+		}
+	})
+	return keys
+}
+
 func (v *Validator[T]) printSlice(rv reflect.Value) error {
 
 	var d = v.deep
@@ -318,9 +484,13 @@ func (v *Validator[T]) printSlice(rv reflect.Value) error {
 		v.visited[rv.Pointer()] = true
 	}
 
+	var path = v.path
+
 	for i := 0; i < rv.Len(); i++ {
 		value := rv.Index(i)
+		v.path = fmt.Sprintf("%s[%d]", path, i)
 		var err = v.format(value)
+		v.path = path
 		if err != nil {
 			return err
 		}
@@ -348,14 +518,21 @@ func (v *Validator[T]) printStruct(rv reflect.Value) error {
 
 		typ := rt.Field(i)
 
-		if err := validate(typ, value); err != nil {
-			return err
+		if err := validate(rv, typ, value); err != nil {
+			if !v.all {
+				return err
+			}
+			v.collect(err)
+			continue
 		}
 
 		// if is private
 		// config private & public
 		if value.CanInterface() {
+			var path = v.path
+			v.path = joinPath(path, fieldKey(typ))
 			var err = v.format(value)
+			v.path = path
 			if err != nil {
 				return err
 			}
@@ -393,38 +570,87 @@ func (v *Validator[T]) printPtr(rv reflect.Value) error {
 	return nil
 }
 
-func validate(t reflect.StructField, v reflect.Value) error {
+func validate(parent reflect.Value, t reflect.StructField, v reflect.Value) error {
 
 	var tag = t.Tag.Get("validate")
 	if tag == "" {
 		return nil
 	}
+	var exists, err = lookupTag(tag)
+	if err != nil {
+		return err
+	}
+
+	return check(parent, fieldKey(t), v, exists)
+}
+
+// lookupTag parses tag once, a tag that does not parse is not cached.
+func lookupTag(tag string) (*Type, error) {
 	mux.Lock()
-	var exists = globalTags[tag]
-	if exists == nil {
-		var tags = strings.Split(tag, ",")
-		if len(tags) == 0 {
-			mux.Unlock()
-			return nil
-		}
+	defer mux.Unlock()
 
-		var parse = parseTag(tags)
-		globalTags[tag] = &parse
-		exists = &parse
+	if exists := globalTags[tag]; exists != nil {
+		return exists, nil
 	}
-	mux.Unlock()
 
-	var parse = *exists
+	var parse, err = parseTag(splitTag(tag))
+	if err != nil {
+		return nil, err
+	}
+
+	globalTags[tag] = &parse
 
-	//var tags = strings.Split(tag, ",")
-	//if len(tags) == 0 {
-	//	return nil
-	//}
+	return &parse, nil
+}
 
+// splitTag splits the rules at commas, \, is a literal comma
+// and commas in brackets of a regex, like {2,4}, do not split.
+func splitTag(tag string) []string {
+	var res []string
+	var builder strings.Builder
+	var depth = 0
+	for i := 0; i < len(tag); i++ {
+		var c = tag[i]
+		var regex = strings.HasPrefix(builder.String(), "regex")
+		switch {
+		case c == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			builder.WriteByte(',')
+			i++
+			continue
+		case c == '\\' && regex && i+1 < len(tag):
+			// keep escapes like \{ away from the depth
+			builder.WriteByte(c)
+			builder.WriteByte(tag[i+1])
+			i++
+			continue
+		case regex && (c == '(' || c == '[' || c == '{'):
+			depth++
+		case regex && (c == ')' || c == ']' || c == '}') && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			res = append(res, builder.String())
+			builder.Reset()
+			continue
+		}
+		builder.WriteByte(c)
+	}
+	return append(res, builder.String())
+}
+
+func fieldKey(t reflect.StructField) string {
 	var key = t.Tag.Get("json")
-	if key == "" {
+	if index := strings.IndexByte(key, ','); index >= 0 {
+		key = key[:index]
+	}
+	if key == "" || key == "-" {
 		key = t.Name
 	}
+	return key
+}
+
+func check(parent reflect.Value, key string, v reflect.Value, exists *Type) error {
+
+	var parse = *exists
 
 	//var parse = parseTag(tags)
 	if parse.Required {
@@ -753,7 +979,7 @@ func validate(t reflect.StructField, v reflect.Value) error {
 		}
 	}
 
-	return nil
+	return checkRules(parent, key, v, exists)
 }
 
 type Type struct {
@@ -766,9 +992,32 @@ type Type struct {
 	Required bool
 	NonEmpty bool
 	Default  string
+
+	Min      string
+	Max      string
+	Len      string
+	OneOf    []string
+	Regex    *regexp.Regexp
+	Email    bool
+	URL      bool
+	UUID     bool
+	IP       bool
+	CIDR     bool
+	Datetime string
+
+	// Dive holds the rules after dive, they apply to every element
+	Dive *Type
+
+	// Custom holds the rules looked up in RegisterRule
+	Custom []TagRule
 }
 
-func parseTag(arr []string) Type {
+type TagRule struct {
+	Name  string
+	Param string
+}
+
+func parseTag(arr []string) (Type, error) {
 	var t = Type{}
 	for i := 0; i < len(arr); i++ {
 		if arr[i] == "required" {
@@ -781,11 +1030,48 @@ func parseTag(arr []string) Type {
 			continue
 		}
 
-		var arr1 = strings.Split(arr[i], ":")
-		if len(arr1) < 2 {
+		if arr[i] == "dive" {
+			var dive, err = parseTag(arr[i+1:])
+			if err != nil {
+				return t, err
+			}
+			t.Dive = &dive
+			break
+		}
+
+		switch arr[i] {
+		case "email":
+			t.Email = true
+			continue
+		case "url":
+			t.URL = true
+			continue
+		case "uuid":
+			t.UUID = true
+			continue
+		case "ip":
+			t.IP = true
+			continue
+		case "cidr":
+			t.CIDR = true
+			continue
+		}
+
+		// the contract may hold : or = itself, like regex or datetime layouts
+		var index = strings.IndexAny(arr[i], ":=")
+		if index < 0 {
+			if arr[i] == "" {
+				continue
+			}
+			if lookupRule(arr[i]) == nil {
+				return t, errors.New("validate tag " + arr[i] + ": unknown rule")
+			}
+			t.Custom = append(t.Custom, TagRule{Name: arr[i]})
 			continue
 		}
 
+		var arr1 = []string{arr[i][:index], arr[i][index+1:]}
+
 		switch arr1[0] {
 		case "gte":
 			t.Gte = arr1[1]
@@ -799,8 +1085,29 @@ func parseTag(arr []string) Type {
 			t.Eq = arr1[1]
 		case "default":
 			t.Default = arr1[1]
+		case "min":
+			t.Min = arr1[1]
+		case "max":
+			t.Max = arr1[1]
+		case "len":
+			t.Len = arr1[1]
+		case "oneof":
+			t.OneOf = strings.Fields(arr1[1])
+		case "regex":
+			var regex, err = regexp.Compile(arr1[1])
+			if err != nil {
+				return t, errors.Wrap(err, "validate tag "+arr[i])
+			}
+			t.Regex = regex
+		case "datetime":
+			t.Datetime = arr1[1]
+		default:
+			if lookupRule(arr1[0]) == nil {
+				return t, errors.New("validate tag " + arr[i] + ": unknown rule")
+			}
+			t.Custom = append(t.Custom, TagRule{Name: arr1[0], Param: arr1[1]})
 		}
 	}
 
-	return t
+	return t, nil
 }
@@ -0,0 +1,56 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 20:05
+**/
+
+package socket
+
+import (
+	"strconv"
+
+	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
+)
+
+// RateLimit returns a Before hook that limits a route or group with policy.
+func RateLimit[T Packer](policy *ratelimit.Policy) router.Before[*Stream[T]] {
+	return func(stream *Stream[T]) error {
+		return Limit(stream, policy)
+	}
+}
+
+// Limit takes a token for stream from policy, when there is none
+// it carries out policy.Action and returns errors.RateLimited.
+func Limit[T Packer](stream *Stream[T], policy *ratelimit.Policy) error {
+	if ok, _ := policy.Allow(limitKey(stream, policy.Scope)); ok {
+		return nil
+	}
+
+	switch policy.Action {
+	case ratelimit.Reply:
+		// a plain error so policy.Code wins over the code of errors.RateLimited
+		_ = ReplyWithError(stream, errors.New(errors.MessageOf(errors.RateLimited)), policy.Code)
+	case ratelimit.Disconnect:
+		if conn, ok := any(stream.conn).(interface{ Close() error }); ok {
+			_ = conn.Close()
+		}
+	}
+
+	return errors.Wrap(errors.RateLimited, stream.event)
+}
+
+func limitKey[T Packer](stream *Stream[T], scope ratelimit.Scope) string {
+	switch scope {
+	case ratelimit.Conn:
+		if conn, ok := any(stream.conn).(interface{ FD() int64 }); ok {
+			return strconv.FormatInt(conn.FD(), 10)
+		}
+	case ratelimit.IP:
+		if conn, ok := any(stream.conn).(interface{ ClientIP() string }); ok {
+			return conn.ClientIP()
+		}
+	}
+	return ""
+}
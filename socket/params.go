@@ -10,8 +10,42 @@
 
 package socket
 
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
 type Params map[string]string
 
 func (ps Params) Get(name string) string {
 	return ps[name]
 }
+
+func (ps Params) Int(name string) (int, error) {
+	var v, err = strconv.Atoi(ps[name])
+	if err != nil {
+		return 0, errors.Wrap(errors.Invalid, "param "+name+" must be int but got '"+ps[name]+"'")
+	}
+	return v, nil
+}
+
+func (ps Params) Int64(name string) (int64, error) {
+	var v, err = strconv.ParseInt(ps[name], 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(errors.Invalid, "param "+name+" must be int64 but got '"+ps[name]+"'")
+	}
+	return v, nil
+}
+
+// UUID returns the param in lower case.
+func (ps Params) UUID(name string) (string, error) {
+	if !uuidPattern.MatchString(ps[name]) {
+		return "", errors.Wrap(errors.Invalid, "param "+name+" must be uuid but got '"+ps[name]+"'")
+	}
+	return strings.ToLower(ps[name]), nil
+}
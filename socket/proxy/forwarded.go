@@ -0,0 +1,187 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 19:05
+**/
+
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/lemonyxk/kitty/kitty/header"
+)
+
+// ClientIP walks the forwarding chain of r from right to left,
+// skipping hops in trusted, and returns the first untrusted one.
+// Headers are ignored unless the peer itself is trusted.
+func ClientIP(r *http.Request, trusted Trusted) string {
+	var remote = stripPort(r.RemoteAddr)
+
+	if !trusted.ContainsHost(remote) {
+		return remote
+	}
+
+	var chain = forwardedFor(r)
+	if len(chain) == 0 {
+		if ip := stripPort(r.Header.Get(header.XRealIP)); net.ParseIP(ip) != nil {
+			return ip
+		}
+		return remote
+	}
+
+	var client = remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		var ip = stripPort(chain[i])
+		if net.ParseIP(ip) == nil {
+			// obfuscated or garbage, the last trusted hop is the best we know
+			break
+		}
+		client = ip
+		if !trusted.Contains(net.ParseIP(ip)) {
+			break
+		}
+	}
+
+	return client
+}
+
+// Host returns the host asked by the client when the peer is trusted.
+// Like ClientIP, the chain is read from the right so values the client
+// put in front of the trusted hops are ignored.
+func Host(r *http.Request, trusted Trusted) string {
+	if trusted.ContainsHost(r.RemoteAddr) {
+		if host := forwardedParam(r, "host", trusted); host != "" {
+			return host
+		}
+		if host := lastValue(r.Header.Values(header.XForwardedHost)); host != "" {
+			return host
+		}
+		if host := r.Header.Get(header.XRealHost); host != "" {
+			return host
+		}
+	}
+	return r.Host
+}
+
+// Scheme returns the scheme used by the client when the peer is trusted,
+// read from the right like Host.
+func Scheme(r *http.Request, trusted Trusted) string {
+	if trusted.ContainsHost(r.RemoteAddr) {
+		if proto := forwardedParam(r, "proto", trusted); proto != "" {
+			return strings.ToLower(proto)
+		}
+		if proto := lastValue(r.Header.Values(header.XForwardedProto)); proto != "" {
+			return strings.ToLower(proto)
+		}
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func forwardedFor(r *http.Request) []string {
+	var elements = forwarded(r)
+	if len(elements) != 0 {
+		var res []string
+		for i := 0; i < len(elements); i++ {
+			if v, ok := elements[i]["for"]; ok {
+				res = append(res, v)
+			}
+		}
+		return res
+	}
+
+	var res []string
+	var values = r.Header.Values(header.XForwardedFor)
+	for i := 0; i < len(values); i++ {
+		var list = strings.Split(values[i], ",")
+		for j := 0; j < len(list); j++ {
+			if v := strings.TrimSpace(list[j]); v != "" {
+				res = append(res, v)
+			}
+		}
+	}
+	return res
+}
+
+// forwardedParam walks the elements from the right, each one added by the
+// hop named in the for of the element after it, and keeps the value set by
+// the outermost hop that is still trusted.
+func forwardedParam(r *http.Request, key string, trusted Trusted) string {
+	var elements = forwarded(r)
+	var res = ""
+	for i := len(elements) - 1; i >= 0; i-- {
+		if v := elements[i][key]; v != "" {
+			res = v
+		}
+		var ip = net.ParseIP(stripPort(elements[i]["for"]))
+		if ip == nil || !trusted.Contains(ip) {
+			break
+		}
+	}
+	return res
+}
+
+// forwarded parses RFC 7239, one map per comma separated element.
+func forwarded(r *http.Request) []map[string]string {
+	var values = r.Header.Values(header.Forwarded)
+	var res []map[string]string
+	for i := 0; i < len(values); i++ {
+		for _, element := range splitQuoted(values[i], ',') {
+			var pairs = make(map[string]string)
+			for _, pair := range splitQuoted(element, ';') {
+				var index = strings.IndexByte(pair, '=')
+				if index < 0 {
+					continue
+				}
+				var k = strings.ToLower(strings.TrimSpace(pair[:index]))
+				var v = strings.Trim(strings.TrimSpace(pair[index+1:]), `"`)
+				pairs[k] = v
+			}
+			if len(pairs) != 0 {
+				res = append(res, pairs)
+			}
+		}
+	}
+	return res
+}
+
+func splitQuoted(s string, sep byte) []string {
+	var res []string
+	var quoted = false
+	var start = 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				res = append(res, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(res, s[start:])
+}
+
+// lastValue returns the value appended by the nearest hop.
+func lastValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	var list = strings.Split(values[len(values)-1], ",")
+	return strings.TrimSpace(list[len(list)-1])
+}
+
+// stripPort handles 1.2.3.4, 1.2.3.4:80, [::1]:80 and ::1.
+func stripPort(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
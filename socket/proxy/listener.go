@@ -0,0 +1,262 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 18:10
+**/
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// https://www.haproxy.org/download/2.9/doc/proxy-protocol.txt
+
+var v1Prefix = []byte("PROXY ")
+
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+const v1MaxLen = 107
+
+const v2HeadLen = 16
+
+// NewListener wraps l so every accepted conn strips the PROXY protocol header
+// sent by a trusted peer and reports the real client through RemoteAddr.
+// With an empty trusted list the header is accepted from nobody,
+// any client could claim to be anyone otherwise.
+// A conn without a header is passed through untouched.
+func NewListener(l net.Listener, trusted Trusted, timeout time.Duration) net.Listener {
+	return &Listener{Listener: l, Trusted: trusted, Timeout: timeout}
+}
+
+type Listener struct {
+	net.Listener
+	Trusted Trusted
+	Timeout time.Duration
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	var trusted = l.Trusted.ContainsAddr(conn.RemoteAddr())
+
+	return &Conn{
+		Conn:    conn,
+		reader:  bufio.NewReader(conn),
+		trusted: trusted,
+		timeout: l.Timeout,
+	}, nil
+}
+
+// Conn reads the header lazily on the first Read, RemoteAddr or LocalAddr,
+// so the accept loop is never blocked by a slow peer.
+type Conn struct {
+	net.Conn
+	reader   *bufio.Reader
+	trusted  bool
+	timeout  time.Duration
+	once     sync.Once
+	mux      sync.Mutex
+	deadline time.Time
+	src      net.Addr
+	dst      net.Addr
+	err      error
+}
+
+func (c *Conn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// ProxyAddr is the address of the peer that sent the header.
+func (c *Conn) ProxyAddr() net.Addr {
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) Handshake() error {
+	c.once.Do(c.handshake)
+	return c.err
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	if err := c.Handshake(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(b)
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	if c.Handshake() == nil && c.src != nil {
+		return c.src
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	if c.Handshake() == nil && c.dst != nil {
+		return c.dst
+	}
+	return c.Conn.LocalAddr()
+}
+
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.mux.Lock()
+	c.deadline = t
+	c.mux.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mux.Lock()
+	c.deadline = t
+	c.mux.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *Conn) handshake() {
+	if !c.trusted {
+		return
+	}
+
+	if c.timeout != 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer func() {
+			// restore the deadline the owner asked for
+			c.mux.Lock()
+			_ = c.Conn.SetReadDeadline(c.deadline)
+			c.mux.Unlock()
+		}()
+	}
+
+	first, err := c.reader.Peek(1)
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	switch first[0] {
+	case v1Prefix[0]:
+		prefix, err := c.reader.Peek(len(v1Prefix))
+		if err != nil {
+			c.err = err
+			return
+		}
+		if bytes.Equal(prefix, v1Prefix) {
+			c.src, c.dst, c.err = readV1(c.reader)
+		}
+	case v2Signature[0]:
+		c.src, c.dst, c.err = readV2(c.reader)
+	}
+}
+
+func readV1(reader *bufio.Reader) (net.Addr, net.Addr, error) {
+	var line []byte
+	for len(line) <= v1MaxLen {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+
+	if !bytes.HasPrefix(line, v1Prefix) || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, errors.Wrap(errors.Invalid, "proxy v1 header")
+	}
+
+	var fields = strings.Fields(string(line[len(v1Prefix) : len(line)-2]))
+	if len(fields) == 0 {
+		return nil, nil, errors.Wrap(errors.Invalid, "proxy v1 header")
+	}
+
+	if fields[0] == "UNKNOWN" {
+		return nil, nil, nil
+	}
+
+	if len(fields) != 5 || (fields[0] != "TCP4" && fields[0] != "TCP6") {
+		return nil, nil, errors.Wrap(errors.Invalid, "proxy v1 header")
+	}
+
+	var srcIP, dstIP = net.ParseIP(fields[1]), net.ParseIP(fields[2])
+	srcPort, err1 := strconv.ParseUint(fields[3], 10, 16)
+	dstPort, err2 := strconv.ParseUint(fields[4], 10, 16)
+	if srcIP == nil || dstIP == nil || err1 != nil || err2 != nil {
+		return nil, nil, errors.Wrap(errors.Invalid, "proxy v1 address")
+	}
+
+	return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, &net.TCPAddr{IP: dstIP, Port: int(dstPort)}, nil
+}
+
+func readV2(reader *bufio.Reader) (net.Addr, net.Addr, error) {
+	head, err := reader.Peek(v2HeadLen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !bytes.Equal(head[:len(v2Signature)], v2Signature) {
+		// not a header at all, leave the bytes to the application
+		return nil, nil, nil
+	}
+
+	var version, command = head[12] >> 4, head[12] & 0x0F
+	var family, transport = head[13] >> 4, head[13] & 0x0F
+	var length = int(binary.BigEndian.Uint16(head[14:16]))
+
+	if version != 2 || command > 1 {
+		return nil, nil, errors.Wrap(errors.Invalid, "proxy v2 header")
+	}
+
+	if _, err = reader.Discard(v2HeadLen); err != nil {
+		return nil, nil, err
+	}
+
+	var body = make([]byte, length)
+	if _, err = io.ReadFull(reader, body); err != nil {
+		return nil, nil, err
+	}
+
+	// LOCAL: health checks from the proxy itself
+	if command == 0 {
+		return nil, nil, nil
+	}
+
+	var ipLen int
+	switch family {
+	case 1:
+		ipLen = net.IPv4len
+	case 2:
+		ipLen = net.IPv6len
+	default:
+		// AF_UNSPEC or AF_UNIX, keep the real peer
+		return nil, nil, nil
+	}
+
+	if len(body) < 2*ipLen+4 {
+		return nil, nil, errors.Wrap(errors.Invalid, "proxy v2 address")
+	}
+
+	var srcIP = net.IP(append([]byte{}, body[:ipLen]...))
+	var dstIP = net.IP(append([]byte{}, body[ipLen:2*ipLen]...))
+	var srcPort = int(binary.BigEndian.Uint16(body[2*ipLen:]))
+	var dstPort = int(binary.BigEndian.Uint16(body[2*ipLen+2:]))
+
+	if transport == 2 {
+		return &net.UDPAddr{IP: srcIP, Port: srcPort}, &net.UDPAddr{IP: dstIP, Port: dstPort}, nil
+	}
+
+	return &net.TCPAddr{IP: srcIP, Port: srcPort}, &net.TCPAddr{IP: dstIP, Port: dstPort}, nil
+}
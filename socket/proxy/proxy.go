@@ -0,0 +1,82 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 18:02
+**/
+
+package proxy
+
+import (
+	"net"
+	"strings"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// Trusted is a list of networks whose peers are allowed
+// to speak for the real client.
+type Trusted []*net.IPNet
+
+// ParseTrusted accepts CIDRs like 10.0.0.0/8 and bare addresses like 127.0.0.1.
+func ParseTrusted(list ...string) (Trusted, error) {
+	var res Trusted
+	for i := 0; i < len(list); i++ {
+		var item = strings.TrimSpace(list[i])
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			var ip = net.ParseIP(item)
+			if ip == nil {
+				return nil, errors.Wrap(errors.Invalid, item)
+			}
+			var bits = 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, errors.Wrap(err, item)
+		}
+		res = append(res, network)
+	}
+	return res, nil
+}
+
+func (t Trusted) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for i := 0; i < len(t); i++ {
+		if t[i].Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Trusted) ContainsHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return t.Contains(net.ParseIP(strings.Trim(host, "[]")))
+}
+
+func (t Trusted) ContainsAddr(addr net.Addr) bool {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return t.Contains(a.IP)
+	case *net.UDPAddr:
+		return t.Contains(a.IP)
+	case nil:
+		return false
+	default:
+		return t.ContainsHost(a.String())
+	}
+}
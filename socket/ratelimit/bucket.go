@@ -0,0 +1,66 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 19:40
+**/
+
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket refilled with rate tokens per second, holding at most burst.
+type Bucket struct {
+	mux    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewBucket(rate float64, burst int) *Bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Bucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Take removes one token, when the bucket is empty it reports
+// how long to wait for the next one.
+func (b *Bucket) Take() (bool, time.Duration) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	var now = time.Now()
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if b.rate <= 0 {
+		return false, time.Second
+	}
+
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// Full reports whether the bucket has been refilled completely,
+// a full bucket carries no state and can be dropped.
+func (b *Bucket) Full() bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.refill(time.Now())
+	return b.tokens >= b.burst
+}
+
+func (b *Bucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
@@ -0,0 +1,108 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 20:55
+**/
+
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// Conns caps the live connections, in total and per client ip,
+// and how many new ones are accepted per second.
+// A zero value disables the matching limit.
+type Conns struct {
+	Max         int
+	MaxPerIP    int
+	AcceptRate  float64
+	AcceptBurst int
+
+	once   sync.Once
+	mux    sync.Mutex
+	bucket *Bucket
+	total  int
+	ips    map[string]int
+}
+
+// Accept takes an accept token and a slot, it is called as soon as
+// a conn is accepted, before its real address may be known.
+func (c *Conns) Accept() error {
+	c.once.Do(c.init)
+
+	if c.bucket != nil {
+		if ok, _ := c.bucket.Take(); !ok {
+			return errors.Wrap(errors.RateLimited, "accept")
+		}
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.Max > 0 && c.total >= c.Max {
+		return errors.Wrap(errors.TooManyConnections, "server")
+	}
+
+	c.total++
+	return nil
+}
+
+// Release gives back the slot taken by Accept.
+func (c *Conns) Release() {
+	c.mux.Lock()
+	c.total--
+	c.mux.Unlock()
+}
+
+// AcquireIP takes a slot for ip, Accept must have succeeded before.
+func (c *Conns) AcquireIP(ip string) error {
+	if c.MaxPerIP <= 0 {
+		return nil
+	}
+
+	c.once.Do(c.init)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.ips[ip] >= c.MaxPerIP {
+		return errors.Wrap(errors.TooManyConnections, ip)
+	}
+
+	c.ips[ip]++
+	return nil
+}
+
+// ReleaseIP gives back the slot taken by AcquireIP.
+func (c *Conns) ReleaseIP(ip string) {
+	if c.MaxPerIP <= 0 {
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if c.ips[ip] <= 1 {
+		delete(c.ips, ip)
+		return
+	}
+
+	c.ips[ip]--
+}
+
+func (c *Conns) init() {
+	if c.AcceptRate > 0 {
+		c.bucket = NewBucket(c.AcceptRate, c.AcceptBurst)
+	}
+	c.ips = make(map[string]int)
+}
+
+// Len is the number of slots in use.
+func (c *Conns) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.total
+}
@@ -0,0 +1,147 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 19:52
+**/
+
+package ratelimit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+type Scope int
+
+const (
+	Global Scope = iota
+	Conn
+	IP
+)
+
+type Action int
+
+const (
+	// Drop the frame and report it through OnError.
+	Drop Action = iota
+	// Reply to the frame with Code and an empty body.
+	Reply
+	// Disconnect the client.
+	Disconnect
+)
+
+const (
+	// DefaultMaxKeys bounds the buckets kept by the Conn and IP scopes.
+	DefaultMaxKeys = 65536
+	// DefaultIdle drops buckets unused for this long.
+	DefaultIdle = time.Minute
+)
+
+// Policy limits to Rate frames or requests per second with bursts of Burst,
+// shared by everyone, or counted per connection or per client ip.
+// HTTP always answers 429, Action and Code only apply to sockets,
+// Code is the code of errors.RateLimited when not set.
+// Per key buckets are kept in least recently used order, at most MaxKeys
+// of them, and the ones idle longer than Idle are swept once per Idle.
+type Policy struct {
+	Rate    float64
+	Burst   int
+	Scope   Scope
+	Action  Action
+	Code    uint32
+	MaxKeys int
+	Idle    time.Duration
+
+	once    sync.Once
+	mux     sync.Mutex
+	global  *Bucket
+	lru     *list.List
+	buckets map[string]*list.Element
+	swept   time.Time
+}
+
+type entry struct {
+	key    string
+	bucket *Bucket
+	seen   time.Time
+}
+
+// Allow takes a token for key, key is ignored by the Global scope.
+func (p *Policy) Allow(key string) (bool, time.Duration) {
+	p.once.Do(func() {
+		if p.Code == 0 {
+			p.Code = errors.RateLimited.Code()
+		}
+		p.global = NewBucket(p.Rate, p.Burst)
+		p.lru = list.New()
+		p.buckets = make(map[string]*list.Element)
+		p.swept = time.Now()
+	})
+
+	if p.Scope == Global {
+		return p.global.Take()
+	}
+
+	var now = time.Now()
+
+	p.mux.Lock()
+	if now.Sub(p.swept) >= p.idle() {
+		p.sweep(now)
+	}
+	var element = p.buckets[key]
+	if element == nil {
+		element = p.lru.PushFront(&entry{key: key, bucket: NewBucket(p.Rate, p.Burst)})
+		p.buckets[key] = element
+		if p.lru.Len() > p.maxKeys() {
+			p.remove(p.lru.Back())
+		}
+	} else {
+		p.lru.MoveToFront(element)
+	}
+	var e = element.Value.(*entry)
+	e.seen = now
+	p.mux.Unlock()
+
+	return e.bucket.Take()
+}
+
+// sweep drops buckets from the cold end until it meets one seen recently,
+// a bucket idle that long has been refilled anyway.
+func (p *Policy) sweep(now time.Time) {
+	p.swept = now
+	for element := p.lru.Back(); element != nil; element = p.lru.Back() {
+		if now.Sub(element.Value.(*entry).seen) < p.idle() {
+			return
+		}
+		p.remove(element)
+	}
+}
+
+func (p *Policy) remove(element *list.Element) {
+	p.lru.Remove(element)
+	delete(p.buckets, element.Value.(*entry).key)
+}
+
+func (p *Policy) idle() time.Duration {
+	var idle = p.Idle
+	if idle <= 0 {
+		idle = DefaultIdle
+	}
+	// never forget a bucket before it could have refilled
+	if p.Rate > 0 {
+		if refill := time.Duration(float64(p.Burst) / p.Rate * float64(time.Second)); refill > idle {
+			idle = refill
+		}
+	}
+	return idle
+}
+
+func (p *Policy) maxKeys() int {
+	if p.MaxKeys <= 0 {
+		return DefaultMaxKeys
+	}
+	return p.MaxKeys
+}
@@ -0,0 +1,108 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 22:10
+**/
+
+package socket
+
+import (
+	"strconv"
+
+	"github.com/lemonyxk/kitty/errors"
+	json "github.com/lemonyxk/kitty/json"
+	"github.com/lemonyxk/kitty/socket/protocol"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// ErrorBody is the json payload of an error frame.
+type ErrorBody struct {
+	Code    uint32 `json:"code"`
+	Message string `json:"message"`
+}
+
+// ReplyWithError answers the message of stream with the code declared by err,
+// or code when it declares none. The public message of err is encoded like
+// the request: ErrorBody for json, a StringValue for protobuf and raw text otherwise.
+func ReplyWithError[T Packer](stream *Stream[T], err error, code uint32) error {
+	return stream.replyError(err, code)
+}
+
+func (s *sender[T]) replyError(err error, code uint32) error {
+	if !s.answer() {
+		return errors.Wrap(errors.Timeout, s.event)
+	}
+
+	if c, ok := errors.CodeOf(err); ok && c != 0 {
+		code = c
+	}
+
+	return s.packError(code, errors.MessageOf(err))
+}
+
+// packError sends the error frame whoever answers, see replyError.
+func (s *sender[T]) packError(code uint32, message string) error {
+	var messageType = s.messageType
+	var body []byte
+	var e error
+
+	switch messageType {
+	case protocol.Json:
+		body, e = json.Marshal(ErrorBody{Code: code, Message: message})
+	case protocol.ProtoBuf:
+		body, e = proto.Marshal(wrapperspb.String(message))
+	default:
+		messageType = protocol.Bin
+		body = []byte(message)
+	}
+
+	if e != nil {
+		return e
+	}
+
+	return s.conn.Pack(s.order, messageType, code, s.messageID, []byte(s.event), body)
+}
+
+// ReplyError is returned by AsyncClient when the answer carries a non zero code.
+type ReplyError struct {
+	Event   string
+	Code    uint32
+	Message string
+	Data    []byte
+}
+
+func (e *ReplyError) Error() string {
+	if e.Message == "" {
+		return e.Event + ": code " + strconv.FormatUint(uint64(e.Code), 10)
+	}
+	return e.Event + ": code " + strconv.FormatUint(uint64(e.Code), 10) + ": " + e.Message
+}
+
+// Is matches registered errors by code, so errors.Is(err, errors.RouteNotFount)
+// holds on the client side too.
+func (e *ReplyError) Is(target error) bool {
+	t, ok := target.(interface{ Code() uint32 })
+	return ok && t.Code() == e.Code
+}
+
+func newReplyError[T Packer](stream *Stream[T]) *ReplyError {
+	var res = &ReplyError{Event: stream.event, Code: stream.code, Data: stream.data}
+
+	switch stream.messageType {
+	case protocol.Json:
+		var body ErrorBody
+		if json.Unmarshal(stream.data, &body) == nil {
+			res.Message = body.Message
+		}
+	case protocol.ProtoBuf:
+		var body wrapperspb.StringValue
+		if proto.Unmarshal(stream.data, &body) == nil {
+			res.Message = body.Value
+		}
+	default:
+		res.Message = string(stream.data)
+	}
+
+	return res
+}
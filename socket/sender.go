@@ -33,6 +33,27 @@ type sender[T Packer] struct {
 	messageID   uint64
 	order       uint32
 	messageType byte
+
+	// state is set once by whoever answers first, the handler or WithTimeout
+	state int32
+}
+
+const (
+	open int32 = iota
+	answered
+	timedOut
+)
+
+// answer claims the reply for the handler, false once the timeout answered.
+func (s *sender[T]) answer() bool {
+	atomic.CompareAndSwapInt32(&s.state, open, answered)
+	return atomic.LoadInt32(&s.state) != timedOut
+}
+
+// TimedOut reports whether the timeout reply has been sent, anything
+// the handler sends after it is dropped.
+func (s *sender[T]) TimedOut() bool {
+	return atomic.LoadInt32(&s.state) == timedOut
 }
 
 func (s *sender[T]) Conn() T {
@@ -87,6 +108,12 @@ func (s *sender[T]) ProtoBufEmit(event string, data proto.Message) error {
 	return s.conn.Pack(s.order, protocol.ProtoBuf, s.code, atomic.AddUint64(&s.messageID, 1), []byte(event), msg)
 }
 
+// RespondWithError answers the current message with the code declared by err,
+// 500 if none, see ReplyWithError.
+func (s *sender[T]) RespondWithError(err error) error {
+	return s.replyError(err, 500)
+}
+
 func (s *sender[T]) Respond(data any) error {
 	switch s.messageType {
 	case protocol.Json:
@@ -41,8 +41,8 @@ type Stream[T Packer] struct {
 	Logger  kitty.Logger
 	Params  Params
 
-	//Node *router.Node[*Stream[T]]
-
+	// Meta is the Data of the matched route
+	Meta any
 }
 
 func (s *Stream[T]) Data() []byte {
@@ -70,6 +70,9 @@ func (s *Stream[T]) Respond(data any) error {
 }
 
 func (s *Stream[T]) Emit(event string, data []byte) error {
+	if !s.answer() {
+		return errors.Wrap(errors.Timeout, s.event)
+	}
 	return s.conn.Pack(s.order, protocol.Bin, s.code, s.messageID, []byte(event), data)
 }
 
@@ -78,6 +81,9 @@ func (s *Stream[T]) JsonEmit(event string, data any) error {
 	if err != nil {
 		return err
 	}
+	if !s.answer() {
+		return errors.Wrap(errors.Timeout, s.event)
+	}
 	return s.conn.Pack(s.order, protocol.Json, s.code, s.messageID, []byte(event), msg)
 }
 
@@ -86,6 +92,9 @@ func (s *Stream[T]) ProtoBufEmit(event string, data proto.Message) error {
 	if err != nil {
 		return err
 	}
+	if !s.answer() {
+		return errors.Wrap(errors.Timeout, s.event)
+	}
 	return s.conn.Pack(s.order, protocol.ProtoBuf, s.code, s.messageID, []byte(event), msg)
 }
 
@@ -11,10 +11,12 @@
 package server
 
 import (
+	"context"
 	"net"
 	"sync"
 	"time"
 
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/socket"
 	"github.com/lemonyxk/kitty/socket/protocol"
 )
@@ -43,9 +45,18 @@ type conn struct {
 	conn     net.Conn
 	lastPing time.Time
 	mux      sync.RWMutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	// logger is made once, when the conn opens
+	logger kitty.Logger
 	protocol.Protocol
 }
 
+// Context is cancelled when the conn is closed.
+func (c *conn) Context() context.Context {
+	return c.ctx
+}
+
 func (c *conn) SetDeadline(t time.Time) error {
 	return c.conn.SetDeadline(t)
 }
@@ -105,6 +116,7 @@ func (c *conn) Push(msg []byte) error {
 }
 
 func (c *conn) Close() error {
+	c.cancel()
 	return c.conn.Close()
 }
 
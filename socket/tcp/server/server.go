@@ -11,15 +11,18 @@
 package server
 
 import (
+	"context"
 	"crypto/tls"
-	"fmt"
 	"net"
 	"sync/atomic"
 	"time"
 
 	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/router"
 	"github.com/lemonyxk/kitty/socket/protocol"
+	"github.com/lemonyxk/kitty/socket/proxy"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
 	"github.com/lemonyxk/kitty/ssl"
 	"github.com/lemonyxk/structure/map"
 
@@ -36,6 +39,12 @@ type Server[T any] struct {
 	// TLS
 	TLSConfig *tls.Config
 
+	// PROXY protocol v1/v2, only parsed from ProxyProtocolTrusted peers,
+	// the list must not be empty when ProxyProtocol is on
+	ProxyProtocol        bool
+	ProxyProtocolTrusted []string
+	ProxyProtocolTimeout time.Duration
+
 	OnClose     func(conn Conn)
 	OnMessage   func(conn Conn, msg []byte)
 	OnOpen      func(conn Conn)
@@ -43,6 +52,23 @@ type Server[T any] struct {
 	OnException func(err error)
 	OnSuccess   func()
 	OnUnknown   func(conn Conn, message []byte, next Middle)
+	OnReject    func(addr net.Addr, err error)
+
+	// 0 means no limit, AcceptRate is conns per second with bursts of AcceptBurst
+	MaxConnections      int
+	MaxConnectionsPerIP int
+	AcceptRate          float64
+	AcceptBurst         int
+
+	// bounds every handler unless the route sets its own timeout,
+	// the client is answered with TimeoutCode, 408 by default
+	HandlerTimeout time.Duration
+	TimeoutCode    uint32
+
+	// answer handler errors and missing routes with a frame carrying the code
+	// of the error, or ErrorCode, 500 by default
+	ReplyError bool
+	ErrorCode  uint32
 
 	HeartBeatTimeout  time.Duration
 	HeartBeatInterval time.Duration
@@ -53,13 +79,18 @@ type Server[T any] struct {
 
 	PingHandler func(conn Conn) func(data string) error
 	PongHandler func(conn Conn) func(data string) error
+	Logger      kitty.Logger
+	RateLimit   *ratelimit.Policy
 	Protocol    protocol.Protocol
 
-	fd        int64
-	senders   *hash.Hash[int64, socket.Emitter[Conn]]
-	router    *router.Router[*socket.Stream[Conn], T]
-	middle    []func(Middle) Middle
-	netListen net.Listener
+	fd           int64
+	logger       kitty.Logger
+	conns        *ratelimit.Conns
+	proxyTrusted proxy.Trusted
+	senders      *hash.Hash[int64, socket.Emitter[Conn]]
+	router       *router.Router[*socket.Stream[Conn], T]
+	middle       []func(Middle) Middle
+	netListen    net.Listener
 }
 
 type Middle router.Middle[*socket.Stream[Conn]]
@@ -106,27 +137,63 @@ func (s *Server[T]) Ready() {
 		s.WriteBufferSize = 8192
 	}
 
+	if s.ProxyProtocol {
+		if len(s.ProxyProtocolTrusted) == 0 {
+			panic("proxy protocol needs ProxyProtocolTrusted")
+		}
+
+		var trusted, err = proxy.ParseTrusted(s.ProxyProtocolTrusted...)
+		if err != nil {
+			panic(err)
+		}
+		s.proxyTrusted = trusted
+
+		if s.ProxyProtocolTimeout == 0 {
+			s.ProxyProtocolTimeout = 3 * time.Second
+		}
+	}
+
+	if s.TimeoutCode == 0 {
+		s.TimeoutCode = errors.Timeout.Code()
+	}
+
+	if s.ErrorCode == 0 {
+		s.ErrorCode = 500
+	}
+
+	if s.Logger == nil {
+		s.Logger = kitty.NewLogger(nil)
+	}
+
+	s.logger = kitty.With(s.Logger, "server", s.Name, "network", "tcp")
+
 	if s.OnOpen == nil {
 		s.OnOpen = func(conn Conn) {
-			fmt.Println("tcp server:", conn.FD(), "is open")
+			s.connLogger(conn).Info("open")
 		}
 	}
 
 	if s.OnClose == nil {
 		s.OnClose = func(conn Conn) {
-			fmt.Println("tcp server:", conn.FD(), "is close")
+			s.connLogger(conn).Info("close")
 		}
 	}
 
 	if s.OnError == nil {
 		s.OnError = func(stream *socket.Stream[Conn], err error) {
-			fmt.Println("tcp server err:", err)
+			stream.Logger.Error(err)
 		}
 	}
 
 	if s.OnException == nil {
 		s.OnException = func(err error) {
-			fmt.Println("tcp server exception:", err)
+			s.logger.Error(err)
+		}
+	}
+
+	if s.OnReject == nil {
+		s.OnReject = func(addr net.Addr, err error) {
+			kitty.With(s.logger, "remote", addr.String()).Warning(err)
 		}
 	}
 
@@ -158,9 +225,29 @@ func (s *Server[T]) Ready() {
 		}
 	}
 
+	s.conns = &ratelimit.Conns{
+		Max:         s.MaxConnections,
+		MaxPerIP:    s.MaxConnectionsPerIP,
+		AcceptRate:  s.AcceptRate,
+		AcceptBurst: s.AcceptBurst,
+	}
+
 	s.senders = hash.New[int64, socket.Emitter[Conn]]()
 }
 
+func (s *Server[T]) connLogger(c Conn) kitty.Logger {
+	if c, ok := c.(*conn); ok && c.logger != nil {
+		return c.logger
+	}
+	return kitty.With(s.logger, "fd", c.FD(), "remote", c.Host())
+}
+
+// streamLogger adds the stream to the logger of its conn on the first log,
+// most streams never log.
+func (s *Server[T]) streamLogger(stream *socket.Stream[Conn]) kitty.Logger {
+	return kitty.Lazy(s.connLogger(stream.Conn()), "event", stream.Event(), "id", stream.MessageID())
+}
+
 func (s *Server[T]) onOpen(conn Conn) {
 	s.addConnect(conn)
 	s.OnOpen(conn)
@@ -173,13 +260,25 @@ func (s *Server[T]) onClose(conn Conn) {
 }
 
 func (s *Server[T]) onError(stream *socket.Stream[Conn], err error) {
+	// the timeout has been answered and reported already
+	if stream.TimedOut() {
+		return
+	}
+	// rate limit policies answer on their own
+	if s.ReplyError && !errors.Is(err, errors.RateLimited) {
+		_ = socket.ReplyWithError(stream, err, s.ErrorCode)
+	}
 	s.OnError(stream, err)
 }
 
-func (s *Server[T]) addConnect(conn Conn) {
+func (s *Server[T]) addConnect(c Conn) {
 	var fd = atomic.AddInt64(&s.fd, 1)
-	s.senders.Set(fd, socket.NewSender(conn))
-	conn.SetFD(fd)
+	c.SetFD(fd)
+	// made once here, before the conn is shared
+	if c, ok := c.(*conn); ok {
+		c.logger = s.connLogger(c)
+	}
+	s.senders.Set(fd, socket.NewSender(c))
 }
 
 func (s *Server[T]) delConnect(conn Conn) {
@@ -212,6 +311,16 @@ func (s *Server[T]) Start() {
 	var err error
 	var netListen net.Listener
 
+	netListen, err = net.Listen("tcp", s.Addr)
+	if err != nil {
+		panic(err)
+	}
+
+	// the PROXY header comes before the TLS handshake
+	if s.ProxyProtocol {
+		netListen = proxy.NewListener(netListen, s.proxyTrusted, s.ProxyProtocolTimeout)
+	}
+
 	if s.CertFile != "" && s.KeyFile != "" || s.TLSConfig != nil {
 		var config *tls.Config
 		if s.TLSConfig != nil {
@@ -222,13 +331,7 @@ func (s *Server[T]) Start() {
 				panic(err)
 			}
 		}
-		netListen, err = tls.Listen("tcp", s.Addr, config)
-	} else {
-		netListen, err = net.Listen("tcp", s.Addr)
-	}
-
-	if err != nil {
-		panic(err)
+		netListen = tls.NewListener(netListen, config)
 	}
 
 	s.netListen = netListen
@@ -244,6 +347,12 @@ func (s *Server[T]) Start() {
 			break
 		}
 
+		// checked before any goroutine is spawned
+		if err := s.conns.Accept(); err != nil {
+			s.reject(conn, peerAddr(conn), err)
+			continue
+		}
+
 		go s.process(conn)
 	}
 }
@@ -252,7 +361,22 @@ func (s *Server[T]) Shutdown() error {
 	return s.netListen.Close()
 }
 
+func (s *Server[T]) reject(netConn net.Conn, addr net.Addr, err error) {
+	_ = netConn.Close()
+	s.OnReject(addr, err)
+}
+
 func (s *Server[T]) process(netConn net.Conn) {
+	defer s.conns.Release()
+
+	// the real address is only known after the PROXY header
+	var ip = stripPort(netConn.RemoteAddr().String())
+	if err := s.conns.AcquireIP(ip); err != nil {
+		s.reject(netConn, netConn.RemoteAddr(), err)
+		return
+	}
+	defer s.conns.ReleaseIP(ip)
+
 	if s.HeartBeatTimeout != 0 {
 		err := netConn.SetDeadline(time.Now().Add(s.HeartBeatTimeout))
 		if err != nil {
@@ -260,24 +384,13 @@ func (s *Server[T]) process(netConn net.Conn) {
 		}
 	}
 
-	switch netConn.(type) {
-	case *tls.Conn:
-		err := netConn.(*tls.Conn).NetConn().(*net.TCPConn).SetReadBuffer(s.ReadBufferSize)
-		if err != nil {
-		panic(err)
-		}
-
-		err = netConn.(*tls.Conn).NetConn().(*net.TCPConn).SetWriteBuffer(s.WriteBufferSize)
-		if err != nil {
-		panic(err)
-		}
-	case *net.TCPConn:
-		err := netConn.(*net.TCPConn).SetReadBuffer(s.ReadBufferSize)
+	if tcpConn := rawConn(netConn); tcpConn != nil {
+		err := tcpConn.SetReadBuffer(s.ReadBufferSize)
 		if err != nil {
 			panic(err)
 		}
 
-		err = netConn.(*net.TCPConn).SetWriteBuffer(s.WriteBufferSize)
+		err = tcpConn.SetWriteBuffer(s.WriteBufferSize)
 		if err != nil {
 			panic(err)
 		}
@@ -290,6 +403,8 @@ func (s *Server[T]) process(netConn net.Conn) {
 		Protocol: s.Protocol,
 	}
 
+	conn.ctx, conn.cancel = context.WithCancel(context.Background())
+
 	s.onOpen(conn)
 
 	var reader = s.Protocol.Reader()
@@ -319,6 +434,35 @@ func (s *Server[T]) process(netConn net.Conn) {
 	s.onClose(conn)
 }
 
+// rawConn unwraps tls and proxy conns down to the tcp conn.
+func rawConn(netConn net.Conn) *net.TCPConn {
+	for {
+		switch c := netConn.(type) {
+		case *net.TCPConn:
+			return c
+		case interface{ NetConn() net.Conn }:
+			netConn = c.NetConn()
+		default:
+			return nil
+		}
+	}
+}
+
+// peerAddr is the address of the peer without waiting for a PROXY header.
+func peerAddr(netConn net.Conn) net.Addr {
+	if tcpConn := rawConn(netConn); tcpConn != nil {
+		return tcpConn.RemoteAddr()
+	}
+	return netConn.RemoteAddr()
+}
+
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
 func (s *Server[T]) decodeMessage(conn Conn, message []byte) error {
 	// unpack
 	order, messageType, code, id, route, body := conn.UnPack(message)
@@ -344,12 +488,31 @@ func (s *Server[T]) decodeMessage(conn Conn, message []byte) error {
 		return s.PongHandler(conn)("")
 	}
 
-	s.middleware(socket.NewStream(conn, order, messageType, code, id, route, body))
+	var stream = socket.NewStream(conn, order, messageType, code, id, route, body)
+	stream.Logger = s.streamLogger(stream)
+	// conns made by the server carry a context, Conn does not require one
+	if c, ok := conn.(interface{ Context() context.Context }); ok {
+		stream.Context = c.Context()
+	}
+
+	if s.RateLimit != nil {
+		if err := socket.Limit(stream, s.RateLimit); err != nil {
+			s.onError(stream, err)
+			return nil
+		}
+	}
+
+	s.middleware(stream)
 
 	return nil
 }
 
 func (s *Server[T]) middleware(stream *socket.Stream[Conn]) {
+	// streams built by OnUnknown
+	if stream.Logger == nil {
+		stream.Logger = s.streamLogger(stream)
+	}
+
 	var next Middle = s.handler
 	for i := len(s.middle) - 1; i >= 0; i-- {
 		next = s.middle[i](next)
@@ -360,43 +523,48 @@ func (s *Server[T]) middleware(stream *socket.Stream[Conn]) {
 func (s *Server[T]) handler(stream *socket.Stream[Conn]) {
 
 	if s.router == nil {
-		if s.OnError != nil {
-			s.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
-		}
+		s.onError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
 		return
 	}
 
-	var n, formatPath = s.router.GetRoute(stream.Event())
+	var n, formatPath = s.router.Lookup(stream.Event())
 	if n == nil {
-		if s.OnError != nil {
-			s.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
-		}
+		s.onError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
 		return
 	}
 
 	var nodeData = n.Data
 
-	//stream.Node = n.Data
+	stream.Meta = n.Data.Data
 
 	stream.Params = n.ParseParams(formatPath)
 
+	var timeout = nodeData.Timeout
+	if timeout == 0 {
+		timeout = s.HandlerTimeout
+	}
+
+	if timeout != 0 {
+		// the reply has been sent already
+		var cancel = socket.WithTimeout(stream, timeout, s.TimeoutCode, func(err error) {
+			s.OnError(stream, err)
+		})
+		defer cancel()
+	}
+
 	for i := 0; i < len(nodeData.Before); i++ {
 		if err := nodeData.Before[i](stream); err != nil {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
-			if s.OnError != nil {
-				s.OnError(stream, err)
-			}
+			s.onError(stream, err)
 			return
 		}
 	}
 
 	err := nodeData.Function(stream)
 	if err != nil {
-		if s.OnError != nil {
-			s.OnError(stream, err)
-		}
+		s.onError(stream, err)
 		return
 	}
 
@@ -405,9 +573,7 @@ func (s *Server[T]) handler(stream *socket.Stream[Conn]) {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
-			if s.OnError != nil {
-				s.OnError(stream, err)
-			}
+			s.onError(stream, err)
 			return
 		}
 	}
@@ -0,0 +1,51 @@
+/**
+* @program: kitty
+*
+* @create: 2026-10-19 21:45
+**/
+
+package socket
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/lemonyxk/kitty/errors"
+)
+
+// WithTimeout replaces the stream context with a child bounded by timeout.
+// When the deadline passes before cancel is called, the stream is answered
+// with code and the errors.Timeout message encoded like ReplyWithError,
+// then onTimeout gets errors.Timeout.
+// Handlers still running are expected to watch stream.Context, whatever
+// they send afterwards is dropped and stream.TimedOut reports true.
+// Nothing is sent when the handler has answered before the deadline.
+func WithTimeout[T Packer](stream *Stream[T], timeout time.Duration, code uint32, onTimeout func(err error)) (cancel func()) {
+	var parent context.Context = stream.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancelCtx := context.WithTimeout(parent, timeout)
+	stream.Context = ctx
+
+	var stop = context.AfterFunc(ctx, func() {
+		// the conn is gone, nobody to answer
+		if ctx.Err() != context.DeadlineExceeded {
+			return
+		}
+		if !atomic.CompareAndSwapInt32(&stream.state, open, timedOut) {
+			return
+		}
+		var err = errors.Wrap(errors.Timeout, stream.event)
+		// encoded like ReplyWithError, but with code
+		_ = stream.packError(code, errors.MessageOf(err))
+		onTimeout(err)
+	})
+
+	return func() {
+		stop()
+		cancelCtx()
+	}
+}
@@ -12,12 +12,14 @@ package server
 
 import "C"
 import (
+	"context"
 	"net"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/socket"
 	"github.com/lemonyxk/kitty/socket/protocol"
 )
@@ -56,9 +58,18 @@ type conn struct {
 	close        chan struct{}
 	mtu          int
 	netListen    *net.UDPConn
+	ctx          context.Context
+	cancel       context.CancelFunc
+	// logger is made once, when the conn opens
+	logger kitty.Logger
 	protocol.UDPProtocol
 }
 
+// Context is cancelled when the conn is closed.
+func (c *conn) Context() context.Context {
+	return c.ctx
+}
+
 func (c *conn) SetDeadline(t time.Time) error {
 	c.timeoutTimer.Reset(t.Sub(time.Now()))
 	return nil
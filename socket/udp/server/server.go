@@ -11,16 +11,18 @@
 package server
 
 import (
-	"fmt"
+	"context"
 	"net"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/router"
 	"github.com/lemonyxk/kitty/socket"
 	"github.com/lemonyxk/kitty/socket/protocol"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
 	"github.com/lemonyxk/structure/map"
 )
 
@@ -35,6 +37,23 @@ type Server[T any] struct {
 	OnSuccess   func()
 	OnException func(err error)
 	OnUnknown   func(conn Conn, message []byte, next Middle)
+	OnReject    func(addr net.Addr, err error)
+
+	// 0 means no limit, AcceptRate is conns per second with bursts of AcceptBurst
+	MaxConnections      int
+	MaxConnectionsPerIP int
+	AcceptRate          float64
+	AcceptBurst         int
+
+	// bounds every handler unless the route sets its own timeout,
+	// the client is answered with TimeoutCode, 408 by default
+	HandlerTimeout time.Duration
+	TimeoutCode    uint32
+
+	// answer handler errors and missing routes with a frame carrying the code
+	// of the error, or ErrorCode, 500 by default
+	ReplyError bool
+	ErrorCode  uint32
 
 	HeartBeatTimeout  time.Duration
 	HeartBeatInterval time.Duration
@@ -47,9 +66,13 @@ type Server[T any] struct {
 
 	PingHandler func(conn Conn) func(data string) error
 	PongHandler func(conn Conn) func(data string) error
+	Logger      kitty.Logger
+	RateLimit   *ratelimit.Policy
 	Protocol    protocol.UDPProtocol
 
 	fd          int64
+	logger      kitty.Logger
+	conns       *ratelimit.Conns
 	senders     *hash.Hash[int64, socket.Emitter[Conn]]
 	addrMap     *hash.Hash[string, int64]
 	router      *router.Router[*socket.Stream[Conn], T]
@@ -110,27 +133,47 @@ func (s *Server[T]) Ready() {
 		s.Mtu = 512
 	}
 
+	if s.TimeoutCode == 0 {
+		s.TimeoutCode = errors.Timeout.Code()
+	}
+
+	if s.ErrorCode == 0 {
+		s.ErrorCode = 500
+	}
+
+	if s.Logger == nil {
+		s.Logger = kitty.NewLogger(nil)
+	}
+
+	s.logger = kitty.With(s.Logger, "server", s.Name, "network", "udp")
+
 	if s.OnOpen == nil {
 		s.OnOpen = func(conn Conn) {
-			fmt.Println("udp server:", conn.FD(), "is open")
+			s.connLogger(conn).Info("open")
 		}
 	}
 
 	if s.OnClose == nil {
 		s.OnClose = func(conn Conn) {
-			fmt.Println("udp server:", conn.FD(), "is close")
+			s.connLogger(conn).Info("close")
 		}
 	}
 
 	if s.OnError == nil {
 		s.OnError = func(stream *socket.Stream[Conn], err error) {
-			fmt.Println("udp server err:", err)
+			stream.Logger.Error(err)
 		}
 	}
 
 	if s.OnException == nil {
 		s.OnException = func(err error) {
-			fmt.Println("udp server exception:", err)
+			s.logger.Error(err)
+		}
+	}
+
+	if s.OnReject == nil {
+		s.OnReject = func(addr net.Addr, err error) {
+			kitty.With(s.logger, "remote", addr.String()).Warning(err)
 		}
 	}
 
@@ -162,30 +205,66 @@ func (s *Server[T]) Ready() {
 		}
 	}
 
+	s.conns = &ratelimit.Conns{
+		Max:         s.MaxConnections,
+		MaxPerIP:    s.MaxConnectionsPerIP,
+		AcceptRate:  s.AcceptRate,
+		AcceptBurst: s.AcceptBurst,
+	}
+
 	s.senders = hash.New[int64, socket.Emitter[Conn]]()
 	s.addrMap = hash.New[string, int64]()
 }
 
+func (s *Server[T]) connLogger(c Conn) kitty.Logger {
+	if c, ok := c.(*conn); ok && c.logger != nil {
+		return c.logger
+	}
+	return kitty.With(s.logger, "fd", c.FD(), "remote", c.Host())
+}
+
+// streamLogger adds the stream to the logger of its conn on the first log,
+// most streams never log.
+func (s *Server[T]) streamLogger(stream *socket.Stream[Conn]) kitty.Logger {
+	return kitty.Lazy(s.connLogger(stream.Conn()), "event", stream.Event(), "id", stream.MessageID())
+}
+
 func (s *Server[T]) onOpen(conn Conn) {
 	s.addConnect(conn)
 	s.OnOpen(conn)
 }
 
 func (s *Server[T]) onClose(conn Conn) {
+	if s.senders.Get(conn.FD()) != nil {
+		s.conns.ReleaseIP(stripPort(conn.Host()))
+		s.conns.Release()
+	}
 	s.delConnect(conn)
 	s.OnClose(conn)
 	conn.CloseChan() <- struct{}{}
 }
 
 func (s *Server[T]) onError(stream *socket.Stream[Conn], err error) {
+	// the timeout has been answered and reported already
+	if stream.TimedOut() {
+		return
+	}
+	// rate limit policies answer on their own
+	if s.ReplyError && !errors.Is(err, errors.RateLimited) {
+		_ = socket.ReplyWithError(stream, err, s.ErrorCode)
+	}
 	s.OnError(stream, err)
 }
 
-func (s *Server[T]) addConnect(conn Conn) {
+func (s *Server[T]) addConnect(c Conn) {
 	var fd = atomic.AddInt64(&s.fd, 1)
-	s.senders.Set(fd, socket.NewSender(conn))
-	s.addrMap.Set(conn.Host(), fd)
-	conn.SetFD(fd)
+	c.SetFD(fd)
+	// made once here, before the conn is shared
+	if c, ok := c.(*conn); ok {
+		c.logger = s.connLogger(c)
+	}
+	s.senders.Set(fd, socket.NewSender(c))
+	s.addrMap.Set(c.Host(), fd)
 }
 
 func (s *Server[T]) delConnect(conn Conn) {
@@ -306,6 +385,17 @@ func (s *Server[T]) readMessage(addr *net.UDPAddr, message []byte) error {
 			return nil
 		}
 
+		if err := s.conns.Accept(); err != nil {
+			s.reject(addr, err)
+			return nil
+		}
+
+		if err := s.conns.AcquireIP(stripPort(addr.String())); err != nil {
+			s.conns.Release()
+			s.reject(addr, err)
+			return nil
+		}
+
 		var conn = &conn{
 			fd:          0,
 			conn:        addr,
@@ -317,6 +407,8 @@ func (s *Server[T]) readMessage(addr *net.UDPAddr, message []byte) error {
 			UDPProtocol: s.Protocol,
 		}
 
+		conn.ctx, conn.cancel = context.WithCancel(context.Background())
+
 		var heartBeatTimeout = s.HeartBeatTimeout
 		if s.HeartBeatTimeout == 0 {
 			heartBeatTimeout = time.Second
@@ -345,6 +437,7 @@ func (s *Server[T]) readMessage(addr *net.UDPAddr, message []byte) error {
 						s.OnException(err)
 					}
 				case <-conn.close:
+					conn.cancel()
 					conn.timeoutTimer.Stop()
 					return
 				}
@@ -378,6 +471,19 @@ func (s *Server[T]) readMessage(addr *net.UDPAddr, message []byte) error {
 	return nil
 }
 
+// reject answers the open with a close, so the client stops waiting.
+func (s *Server[T]) reject(addr *net.UDPAddr, err error) {
+	_, _ = s.netListen.WriteToUDP(s.Protocol.PackClose(), addr)
+	s.OnReject(addr, err)
+}
+
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
 func (s *Server[T]) decodeMessage(conn Conn, message []byte) error {
 	order, messageType, code, id, route, body := conn.UnPack(message)
 
@@ -403,12 +509,34 @@ func (s *Server[T]) decodeMessage(conn Conn, message []byte) error {
 	}
 
 	// on router
-	s.middleware(socket.NewStream(conn, order, messageType, code, id, route, body))
+	var stream = socket.NewStream(conn, order, messageType, code, id, route, body)
+	stream.Logger = s.streamLogger(stream)
+	// conns made by the server carry a context, Conn does not require one
+	if c, ok := conn.(interface{ Context() context.Context }); ok {
+		stream.Context = c.Context()
+	}
+
+	if s.RateLimit != nil {
+		if err := socket.Limit(stream, s.RateLimit); err != nil {
+			s.onError(stream, err)
+			if s.RateLimit.Action == ratelimit.Disconnect {
+				s.onClose(conn)
+			}
+			return nil
+		}
+	}
+
+	s.middleware(stream)
 
 	return nil
 }
 
 func (s *Server[T]) middleware(stream *socket.Stream[Conn]) {
+	// streams built by OnUnknown
+	if stream.Logger == nil {
+		stream.Logger = s.streamLogger(stream)
+	}
+
 	var next Middle = s.handler
 	for i := len(s.middle) - 1; i >= 0; i-- {
 		next = s.middle[i](next)
@@ -419,43 +547,48 @@ func (s *Server[T]) middleware(stream *socket.Stream[Conn]) {
 func (s *Server[T]) handler(stream *socket.Stream[Conn]) {
 
 	if s.router == nil {
-		if s.OnError != nil {
-			s.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
-		}
+		s.onError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
 		return
 	}
 
-	var n, formatPath = s.router.GetRoute(stream.Event())
+	var n, formatPath = s.router.Lookup(stream.Event())
 	if n == nil {
-		if s.OnError != nil {
-			s.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
-		}
+		s.onError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
 		return
 	}
 
 	var nodeData = n.Data
 
-	//stream.Node = n.Data
+	stream.Meta = n.Data.Data
 
 	stream.Params = n.ParseParams(formatPath)
 
+	var timeout = nodeData.Timeout
+	if timeout == 0 {
+		timeout = s.HandlerTimeout
+	}
+
+	if timeout != 0 {
+		// the reply has been sent already
+		var cancel = socket.WithTimeout(stream, timeout, s.TimeoutCode, func(err error) {
+			s.OnError(stream, err)
+		})
+		defer cancel()
+	}
+
 	for i := 0; i < len(nodeData.Before); i++ {
 		if err := nodeData.Before[i](stream); err != nil {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
-			if s.OnError != nil {
-				s.OnError(stream, err)
-			}
+			s.onError(stream, err)
 			return
 		}
 	}
 
 	err := nodeData.Function(stream)
 	if err != nil {
-		if s.OnError != nil {
-			s.OnError(stream, err)
-		}
+		s.onError(stream, err)
 		return
 	}
 
@@ -464,9 +597,7 @@ func (s *Server[T]) handler(stream *socket.Stream[Conn]) {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
-			if s.OnError != nil {
-				s.OnError(stream, err)
-			}
+			s.onError(stream, err)
 			return
 		}
 	}
@@ -2,13 +2,13 @@ package client
 
 import (
 	"crypto/tls"
-	"fmt"
 	"net"
 	"net/http"
 	"time"
 
 	"github.com/fasthttp/websocket"
 	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/router"
 	"github.com/lemonyxk/kitty/socket"
 	"github.com/lemonyxk/kitty/socket/protocol"
@@ -52,9 +52,11 @@ type Client[T any] struct {
 	PongHandler func(conn Conn) func(data string) error
 
 	Protocol protocol.Protocol
+	Logger   kitty.Logger
 
 	conn                  Conn
 	sender                socket.Emitter[Conn]
+	logger                kitty.Logger
 	router                *router.Router[*socket.Stream[Conn], T]
 	middle                []func(Middle) Middle
 	stopCh                chan struct{}
@@ -97,27 +99,33 @@ func (c *Client[T]) Connect() {
 		panic("addr can not be empty")
 	}
 
+	if c.Logger == nil {
+		c.Logger = kitty.NewLogger(nil)
+	}
+
+	c.logger = kitty.With(c.Logger, "client", c.Name, "network", "websocket", "remote", c.Addr)
+
 	if c.OnOpen == nil {
 		c.OnOpen = func(conn Conn) {
-			fmt.Println("webSocket client: connect success")
+			c.logger.Info("open")
 		}
 	}
 
 	if c.OnClose == nil {
 		c.OnClose = func(conn Conn) {
-			fmt.Println("webSocket client: connection close")
+			c.logger.Info("close")
 		}
 	}
 
 	if c.OnError == nil {
 		c.OnError = func(stream *socket.Stream[Conn], err error) {
-			fmt.Println("webSocket client err:", err)
+			stream.Logger.Error(err)
 		}
 	}
 
 	if c.OnException == nil {
 		c.OnException = func(err error) {
-			fmt.Println("webSocket client exception:", err)
+			c.logger.Error(err)
 		}
 	}
 
@@ -331,12 +339,24 @@ func (c *Client[T]) decodeMessage(messageFrame int, message []byte) error {
 	}
 
 	// on router
-	c.middleware(socket.NewStream(c.conn, order, messageType, code, id, route, body))
+	var stream = socket.NewStream(c.conn, order, messageType, code, id, route, body)
+	stream.Logger = c.streamLogger(stream)
+
+	c.middleware(stream)
 
 	return nil
 }
 
+func (c *Client[T]) streamLogger(stream *socket.Stream[Conn]) kitty.Logger {
+	return kitty.Lazy(c.logger, "event", stream.Event(), "id", stream.MessageID())
+}
+
 func (c *Client[T]) middleware(stream *socket.Stream[Conn]) {
+	// streams built by OnUnknown
+	if stream.Logger == nil {
+		stream.Logger = c.streamLogger(stream)
+	}
+
 	var next Middle = c.handler
 	for i := len(c.middle) - 1; i >= 0; i-- {
 		next = c.middle[i](next)
@@ -353,7 +373,7 @@ func (c *Client[T]) handler(stream *socket.Stream[Conn]) {
 		return
 	}
 
-	var n, formatPath = c.router.GetRoute(stream.Event())
+	var n, formatPath = c.router.Lookup(stream.Event())
 	if n == nil {
 		if c.OnError != nil {
 			c.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
@@ -363,7 +383,7 @@ func (c *Client[T]) handler(stream *socket.Stream[Conn]) {
 
 	var nodeData = n.Data
 
-	//stream.Node = n.Data
+	stream.Meta = n.Data.Data
 
 	stream.Params = n.ParseParams(formatPath)
 
@@ -11,16 +11,16 @@
 package server
 
 import (
-	"net"
+	"context"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/fasthttp/websocket"
-	"github.com/lemonyxk/kitty/kitty/header"
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/socket"
 	"github.com/lemonyxk/kitty/socket/protocol"
+	"github.com/lemonyxk/kitty/socket/proxy"
 )
 
 type Conn interface {
@@ -53,9 +53,19 @@ type conn struct {
 	request      *http.Request
 	mux          sync.Mutex
 	subProtocols []string
+	trusted      proxy.Trusted
+	ctx          context.Context
+	cancel       context.CancelFunc
+	// logger is made once, when the conn opens
+	logger kitty.Logger
 	protocol.Protocol
 }
 
+// Context is cancelled when the conn is closed.
+func (c *conn) Context() context.Context {
+	return c.ctx
+}
+
 func (c *conn) SetDeadline(t time.Time) error {
 	return c.conn.NetConn().SetDeadline(t)
 }
@@ -97,27 +107,11 @@ func (c *conn) SetFD(fd int64) {
 }
 
 func (c *conn) Host() string {
-	if host := c.request.Header.Get(header.Host); host != "" {
-		return host
-	}
-	return c.request.Host
+	return proxy.Host(c.request, c.trusted)
 }
 
 func (c *conn) ClientIP() string {
-
-	if ip := strings.Split(c.request.Header.Get(header.XForwardedFor), ",")[0]; ip != "" {
-		return ip
-	}
-
-	if ip := c.request.Header.Get(header.XRealIP); ip != "" {
-		return ip
-	}
-
-	if ip, _, err := net.SplitHostPort(c.request.RemoteAddr); err == nil {
-		return ip
-	}
-
-	return ""
+	return proxy.ClientIP(c.request, c.trusted)
 }
 
 func (c *conn) SubProtocols() []string {
@@ -140,6 +134,7 @@ func (c *conn) Push(msg []byte) error {
 }
 
 func (c *conn) Close() error {
+	c.cancel()
 	return c.conn.Close()
 }
 
@@ -3,7 +3,6 @@ package server
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
 	"net"
 	"net/http"
 	"strings"
@@ -12,9 +11,12 @@ import (
 
 	"github.com/fasthttp/websocket"
 	"github.com/lemonyxk/kitty/errors"
+	"github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/kitty/header"
 	"github.com/lemonyxk/kitty/router"
 	"github.com/lemonyxk/kitty/socket/protocol"
+	"github.com/lemonyxk/kitty/socket/proxy"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
 	hash "github.com/lemonyxk/structure/map"
 
 	"github.com/lemonyxk/kitty/socket"
@@ -33,6 +35,16 @@ type Server[T any] struct {
 	// Path
 	Path string
 
+	// PROXY protocol v1/v2, only parsed from ProxyProtocolTrusted peers,
+	// the list must not be empty when ProxyProtocol is on
+	ProxyProtocol        bool
+	ProxyProtocolTrusted []string
+	ProxyProtocolTimeout time.Duration
+
+	// peers allowed to set Forwarded, X-Forwarded-* and X-Real-* headers,
+	// nothing is trusted when empty
+	TrustedProxies []string
+
 	OnOpen      func(conn Conn)
 	OnMessage   func(conn Conn, msg []byte)
 	OnClose     func(conn Conn)
@@ -41,6 +53,23 @@ type Server[T any] struct {
 	OnSuccess   func()
 	OnRaw       func(w http.ResponseWriter, r *http.Request)
 	OnUnknown   func(conn Conn, message []byte, next Middle)
+	OnReject    func(addr net.Addr, err error)
+
+	// 0 means no limit, AcceptRate is conns per second with bursts of AcceptBurst
+	MaxConnections      int
+	MaxConnectionsPerIP int
+	AcceptRate          float64
+	AcceptBurst         int
+
+	// bounds every handler unless the route sets its own timeout,
+	// the client is answered with TimeoutCode, 408 by default
+	HandlerTimeout time.Duration
+	TimeoutCode    uint32
+
+	// answer handler errors and missing routes with a frame carrying the code
+	// of the error, or ErrorCode, 500 by default
+	ReplyError bool
+	ErrorCode  uint32
 
 	HeartBeatTimeout  time.Duration
 	HeartBeatInterval time.Duration
@@ -54,6 +83,8 @@ type Server[T any] struct {
 	CheckOrigin  func(r *http.Request) bool
 	PingHandler  func(conn Conn) func(data string) error
 	PongHandler  func(conn Conn) func(data string) error
+	Logger       kitty.Logger
+	RateLimit    *ratelimit.Policy
 
 	ReadTimeout       time.Duration
 	WriteTimeout      time.Duration
@@ -61,13 +92,17 @@ type Server[T any] struct {
 	ReadHeaderTimeout time.Duration
 	MaxHeaderBytes    int
 
-	fd        int64
-	senders   *hash.Hash[int64, socket.Emitter[Conn]]
-	router    *router.Router[*socket.Stream[Conn], T]
-	middle    []func(next Middle) Middle
-	server    *http.Server
-	netListen net.Listener
-	protocol  protocol.Protocol
+	fd           int64
+	logger       kitty.Logger
+	conns        *ratelimit.Conns
+	proxyTrusted proxy.Trusted
+	trusted      proxy.Trusted
+	senders      *hash.Hash[int64, socket.Emitter[Conn]]
+	router       *router.Router[*socket.Stream[Conn], T]
+	middle       []func(next Middle) Middle
+	server       *http.Server
+	netListen    net.Listener
+	protocol     protocol.Protocol
 }
 
 type Middle router.Middle[*socket.Stream[Conn]]
@@ -88,10 +123,14 @@ func (s *Server[T]) Sender(fd int64) (socket.Emitter[Conn], error) {
 	return sender, nil
 }
 
-func (s *Server[T]) addConnect(conn Conn) {
+func (s *Server[T]) addConnect(c Conn) {
 	var fd = atomic.AddInt64(&s.fd, 1)
-	s.senders.Set(fd, socket.NewSender(conn))
-	conn.SetFD(fd)
+	c.SetFD(fd)
+	// made once here, before the conn is shared
+	if c, ok := c.(*conn); ok {
+		c.logger = s.connLogger(c)
+	}
+	s.senders.Set(fd, socket.NewSender(c))
 }
 
 func (s *Server[T]) delConnect(conn Conn) {
@@ -117,6 +156,19 @@ func (s *Server[T]) ConnLen() int {
 	return s.senders.Len()
 }
 
+func (s *Server[T]) connLogger(c Conn) kitty.Logger {
+	if c, ok := c.(*conn); ok && c.logger != nil {
+		return c.logger
+	}
+	return kitty.With(s.logger, "fd", c.FD(), "remote", c.ClientIP())
+}
+
+// streamLogger adds the stream to the logger of its conn on the first log,
+// most streams never log.
+func (s *Server[T]) streamLogger(stream *socket.Stream[Conn]) kitty.Logger {
+	return kitty.Lazy(s.connLogger(stream.Conn()), "event", stream.Event(), "id", stream.MessageID())
+}
+
 func (s *Server[T]) onOpen(conn Conn) {
 	s.addConnect(conn)
 	s.OnOpen(conn)
@@ -129,6 +181,14 @@ func (s *Server[T]) onClose(conn Conn) {
 }
 
 func (s *Server[T]) onError(stream *socket.Stream[Conn], err error) {
+	// the timeout has been answered and reported already
+	if stream.TimedOut() {
+		return
+	}
+	// rate limit policies answer on their own
+	if s.ReplyError && !errors.Is(err, errors.RateLimited) {
+		_ = socket.ReplyWithError(stream, err, s.ErrorCode)
+	}
 	s.OnError(stream, err)
 }
 
@@ -167,33 +227,75 @@ func (s *Server[T]) Ready() {
 		s.WriteBufferSize = 8192
 	}
 
+	if s.ProxyProtocol {
+		if len(s.ProxyProtocolTrusted) == 0 {
+			panic("proxy protocol needs ProxyProtocolTrusted")
+		}
+
+		var trusted, err = proxy.ParseTrusted(s.ProxyProtocolTrusted...)
+		if err != nil {
+			panic(err)
+		}
+		s.proxyTrusted = trusted
+
+		if s.ProxyProtocolTimeout == 0 {
+			s.ProxyProtocolTimeout = 3 * time.Second
+		}
+	}
+
+	trusted, err := proxy.ParseTrusted(s.TrustedProxies...)
+	if err != nil {
+		panic(err)
+	}
+	s.trusted = trusted
+
 	if s.CheckOrigin == nil {
 		s.CheckOrigin = func(r *http.Request) bool {
 			return true
 		}
 	}
 
+	if s.TimeoutCode == 0 {
+		s.TimeoutCode = errors.Timeout.Code()
+	}
+
+	if s.ErrorCode == 0 {
+		s.ErrorCode = 500
+	}
+
+	if s.Logger == nil {
+		s.Logger = kitty.NewLogger(nil)
+	}
+
+	s.logger = kitty.With(s.Logger, "server", s.Name, "network", "websocket")
+
 	if s.OnOpen == nil {
 		s.OnOpen = func(conn Conn) {
-			fmt.Println("webSocket server:", conn.FD(), "is open")
+			s.connLogger(conn).Info("open")
 		}
 	}
 
 	if s.OnClose == nil {
 		s.OnClose = func(conn Conn) {
-			fmt.Println("webSocket server:", conn.FD(), "is close")
+			s.connLogger(conn).Info("close")
 		}
 	}
 
 	if s.OnError == nil {
 		s.OnError = func(stream *socket.Stream[Conn], err error) {
-			fmt.Println("webSocket server err:", err)
+			stream.Logger.Error(err)
 		}
 	}
 
 	if s.OnException == nil {
 		s.OnException = func(err error) {
-			fmt.Println("webSocket server exception:", err)
+			s.logger.Error(err)
+		}
+	}
+
+	if s.OnReject == nil {
+		s.OnReject = func(addr net.Addr, err error) {
+			kitty.With(s.logger, "remote", addr.String()).Warning(err)
 		}
 	}
 
@@ -225,6 +327,13 @@ func (s *Server[T]) Ready() {
 		}
 	}
 
+	s.conns = &ratelimit.Conns{
+		Max:         s.MaxConnections,
+		MaxPerIP:    s.MaxConnectionsPerIP,
+		AcceptRate:  s.AcceptRate,
+		AcceptBurst: s.AcceptBurst,
+	}
+
 	s.senders = hash.New[int64, socket.Emitter[Conn]]()
 }
 
@@ -249,6 +358,19 @@ func (s *Server[T]) process(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = s.conns.Accept(); err != nil {
+		s.reject(netConn, err)
+		return
+	}
+	defer s.conns.Release()
+
+	var ip = proxy.ClientIP(r, s.trusted)
+	if err = s.conns.AcquireIP(ip); err != nil {
+		s.reject(netConn, err)
+		return
+	}
+	defer s.conns.ReleaseIP(ip)
+
 	if s.HeartBeatTimeout != 0 {
 		err = netConn.NetConn().SetDeadline(time.Now().Add(s.HeartBeatTimeout))
 		if err != nil {
@@ -264,9 +386,12 @@ func (s *Server[T]) process(w http.ResponseWriter, r *http.Request) {
 		request:      r,
 		lastPing:     time.Now(),
 		subProtocols: upgrade.Subprotocols,
+		trusted:      s.trusted,
 		Protocol:     s.protocol,
 	}
 
+	conn.ctx, conn.cancel = context.WithCancel(r.Context())
+
 	netConn.SetPingHandler(s.PingHandler(conn))
 
 	netConn.SetPongHandler(s.PongHandler(conn))
@@ -305,6 +430,14 @@ func (s *Server[T]) process(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// reject sends 1013 try again later, so the client knows it was not an error.
+func (s *Server[T]) reject(netConn *websocket.Conn, err error) {
+	var msg = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, err.Error())
+	_ = netConn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(s.HandshakeTimeout))
+	_ = netConn.Close()
+	s.OnReject(netConn.RemoteAddr(), err)
+}
+
 func (s *Server[T]) decodeMessage(conn Conn, message []byte) error {
 
 	// unpack
@@ -332,12 +465,31 @@ func (s *Server[T]) decodeMessage(conn Conn, message []byte) error {
 	}
 
 	// on router
-	s.middleware(socket.NewStream(conn, order, messageType, code, id, route, body))
+	var stream = socket.NewStream(conn, order, messageType, code, id, route, body)
+	stream.Logger = s.streamLogger(stream)
+	// conns made by the server carry a context, Conn does not require one
+	if c, ok := conn.(interface{ Context() context.Context }); ok {
+		stream.Context = c.Context()
+	}
+
+	if s.RateLimit != nil {
+		if err := socket.Limit(stream, s.RateLimit); err != nil {
+			s.onError(stream, err)
+			return nil
+		}
+	}
+
+	s.middleware(stream)
 
 	return nil
 }
 
 func (s *Server[T]) middleware(stream *socket.Stream[Conn]) {
+	// streams built by OnUnknown
+	if stream.Logger == nil {
+		stream.Logger = s.streamLogger(stream)
+	}
+
 	var next Middle = s.handler
 	for i := len(s.middle) - 1; i >= 0; i-- {
 		next = s.middle[i](next)
@@ -348,43 +500,48 @@ func (s *Server[T]) middleware(stream *socket.Stream[Conn]) {
 func (s *Server[T]) handler(stream *socket.Stream[Conn]) {
 
 	if s.router == nil {
-		if s.OnError != nil {
-			s.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
-		}
+		s.onError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
 		return
 	}
 
-	var n, formatPath = s.router.GetRoute(stream.Event())
+	var n, formatPath = s.router.Lookup(stream.Event())
 	if n == nil {
-		if s.OnError != nil {
-			s.OnError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
-		}
+		s.onError(stream, errors.Wrap(errors.RouteNotFount, stream.Event()))
 		return
 	}
 
 	var nodeData = n.Data
 
-	//stream.Node = n.Data
+	stream.Meta = n.Data.Data
 
 	stream.Params = n.ParseParams(formatPath)
 
+	var timeout = nodeData.Timeout
+	if timeout == 0 {
+		timeout = s.HandlerTimeout
+	}
+
+	if timeout != 0 {
+		// the reply has been sent already
+		var cancel = socket.WithTimeout(stream, timeout, s.TimeoutCode, func(err error) {
+			s.OnError(stream, err)
+		})
+		defer cancel()
+	}
+
 	for i := 0; i < len(nodeData.Before); i++ {
 		if err := nodeData.Before[i](stream); err != nil {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
-			if s.OnError != nil {
-				s.OnError(stream, err)
-			}
+			s.onError(stream, err)
 			return
 		}
 	}
 
 	err := nodeData.Function(stream)
 	if err != nil {
-		if s.OnError != nil {
-			s.OnError(stream, err)
-		}
+		s.onError(stream, err)
 		return
 	}
 
@@ -393,9 +550,7 @@ func (s *Server[T]) handler(stream *socket.Stream[Conn]) {
 			if errors.Is(err, errors.StopPropagation) {
 				return
 			}
-			if s.OnError != nil {
-				s.OnError(stream, err)
-			}
+			s.onError(stream, err)
 			return
 		}
 	}
@@ -436,6 +591,10 @@ func (s *Server[T]) Start() {
 		panic(err)
 	}
 
+	if s.ProxyProtocol {
+		netListen = proxy.NewListener(netListen, s.proxyTrusted, s.ProxyProtocolTimeout)
+	}
+
 	s.netListen = netListen
 	s.server = &server
 
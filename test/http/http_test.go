@@ -11,22 +11,32 @@
 package http
 
 import (
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"log"
+	"log/slog"
 	http2 "net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/lemonyxk/kitty"
+	"github.com/lemonyxk/kitty/errors"
 	hello "github.com/lemonyxk/kitty/example/protobuf"
+	"github.com/lemonyxk/kitty/json"
 	kitty2 "github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/auth"
 	"github.com/lemonyxk/kitty/socket/http"
 	"github.com/lemonyxk/kitty/socket/http/client"
+	"github.com/lemonyxk/kitty/socket/http/openapi"
 	"github.com/lemonyxk/kitty/socket/http/server"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/proto"
 )
@@ -307,6 +317,119 @@ func Test_HTTP_Params(t *testing.T) {
 	assert.True(t, res.String() == "hello Params!")
 }
 
+func Test_HTTP_ParamsConstraint(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	httpServerRouter.Method("GET").Route("/order/:id<int>").Handler(func(stream *http.Stream[server.Conn]) error {
+		var id, err = stream.Params.Int("id")
+		assert.Nil(t, err)
+		_, err = stream.Params.UUID("id")
+		assert.True(t, errors.Is(err, errors.Invalid))
+		return stream.Sender.String("order " + strconv.Itoa(id+1))
+	})
+
+	httpServerRouter.Method("GET").Route("/order/:id<uuid>/items/*path").Handler(func(stream *http.Stream[server.Conn]) error {
+		var id, err = stream.Params.UUID("id")
+		assert.Nil(t, err)
+		return stream.Sender.String(id + " " + stream.Params.Get("path"))
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	var res = client.Get(ts.URL + "/order/41").Query().Send()
+	assert.Equal(t, "order 42", res.String())
+
+	res = client.Get(ts.URL + "/order/123e4567-e89b-12d3-a456-426614174000/items/a/b").Query().Send()
+	assert.Equal(t, "123e4567-e89b-12d3-a456-426614174000 a/b", res.String())
+
+	res = client.Get(ts.URL + "/order/abc").Query().Send()
+	assert.Equal(t, 404, res.Code())
+}
+
+// AuthData carries a rule through a pointer receiver.
+type AuthData struct {
+	Owner bool
+}
+
+func (d *AuthData) AuthRule() *auth.Rule {
+	if d.Owner {
+		return &auth.Rule{Roles: []string{"owner"}}
+	}
+	return nil
+}
+
+func Test_HTTP_Authorize(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	var identify = func(stream *http.Stream[server.Conn]) error {
+		if role := stream.Request.Header.Get("X-Role"); role != "" {
+			stream.Context = auth.WithIdentity(stream.Context, &auth.User{Roles: []string{role}})
+		}
+		return nil
+	}
+
+	var f = func(stream *http.Stream[server.Conn]) error { return stream.Sender.String("ok") }
+
+	httpServerRouter.Group("/admin").Data(&auth.Rule{Roles: []string{"admin"}}).
+		Before(identify, http.Authorize[server.Conn](nil)).
+		Handler(func(handler *router.Handler[*http.Stream[server.Conn], any]) {
+			handler.Get("/users").Handler(f)
+			handler.Get("/health").Data(&auth.Rule{Public: true}).Handler(f)
+			handler.Get("/stats").Data(auth.Rule{Roles: []string{"ops"}}).Handler(f)
+			handler.Get("/owner").Data(AuthData{Owner: true}).Handler(f)
+			handler.Get("/misc").Data("misc").Handler(f)
+		})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	var res = client.Get(ts.URL + "/admin/users").Query().Send()
+	assert.Equal(t, http2.StatusUnauthorized, res.Code())
+
+	res = client.Get(ts.URL+"/admin/users").SetHeader("X-Role", "user").Query().Send()
+	assert.Equal(t, http2.StatusForbidden, res.Code())
+
+	httpServer.ProblemDetails = true
+	res = client.Get(ts.URL+"/admin/users").SetHeader("X-Role", "user").Query().Send()
+	httpServer.ProblemDetails = false
+	assert.Equal(t, http2.StatusForbidden, res.Code())
+	assert.Equal(t, "application/problem+json", res.Response().Header.Get("Content-Type"))
+
+	res = client.Get(ts.URL+"/admin/users").SetHeader("X-Role", "admin").Query().Send()
+	assert.Equal(t, "ok", res.String())
+
+	res = client.Get(ts.URL + "/admin/health").Query().Send()
+	assert.Equal(t, "ok", res.String())
+
+	res = client.Get(ts.URL+"/admin/stats").SetHeader("X-Role", "admin").Query().Send()
+	assert.Equal(t, http2.StatusForbidden, res.Code())
+	res = client.Get(ts.URL+"/admin/stats").SetHeader("X-Role", "ops").Query().Send()
+	assert.Equal(t, "ok", res.String())
+
+	res = client.Get(ts.URL+"/admin/owner").SetHeader("X-Role", "admin").Query().Send()
+	assert.Equal(t, http2.StatusForbidden, res.Code())
+	res = client.Get(ts.URL+"/admin/owner").SetHeader("X-Role", "owner").Query().Send()
+	assert.Equal(t, "ok", res.String())
+
+	// data that cannot carry a rule fails closed
+	res = client.Get(ts.URL+"/admin/misc").SetHeader("X-Role", "admin").Query().Send()
+	assert.Equal(t, http2.StatusForbidden, res.Code())
+
+	var matrix = auth.Matrix(httpServerRouter)
+	assert.Equal(t, 5, len(matrix))
+	assert.Equal(t, "/admin/health", matrix[0].Route)
+	assert.True(t, matrix[0].Public)
+	assert.False(t, matrix[1].Guarded)
+	assert.Equal(t, []string{"owner"}, matrix[2].Roles)
+	assert.Equal(t, []string{"ops"}, matrix[3].Roles)
+	assert.Equal(t, []string{"admin"}, matrix[4].Roles)
+
+	var buf bytes.Buffer
+	assert.Nil(t, auth.WriteCSV(&buf, matrix))
+	assert.Contains(t, buf.String(), "/admin/users,GET,true,false,admin,")
+}
+
 func Test_HTTP_Protobuf(t *testing.T) {
 
 	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
@@ -350,3 +473,693 @@ func Test_HTTP_Static(t *testing.T) {
 	assert.True(t, len(client.Get(ts.URL+"/1.png").Query().Send().Bytes()) == 2853516)
 	assert.True(t, client.Get(ts.URL+"/test.txt").Query().Send().String() == "hello static!")
 }
+
+func Test_HTTP_TrustedProxies(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	httpServerRouter.Method("GET").Route("/proxy").Handler(func(stream *http.Stream[server.Conn]) error {
+		return stream.Sender.String(stream.ClientIP() + " " + stream.Host() + " " + stream.Scheme())
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	// nothing trusted, headers are ignored
+	var res = client.Get(ts.URL+"/proxy").SetHeader("X-Forwarded-For", "9.9.9.9").Query().Send()
+	assert.True(t, strings.HasPrefix(res.String(), "127.0.0.1 127.0.0.1:"), res.String())
+
+	httpServer.TrustedProxies = []string{"127.0.0.1", "10.0.0.0/8"}
+	httpServer.Ready()
+	defer func() {
+		httpServer.TrustedProxies = nil
+		httpServer.Ready()
+	}()
+
+	res = client.Get(ts.URL+"/proxy").
+		SetHeader("X-Forwarded-For", "6.6.6.6, 9.9.9.9, 10.0.0.2").
+		SetHeader("X-Forwarded-Host", "example.com").
+		SetHeader("X-Forwarded-Proto", "https").
+		Query().Send()
+	assert.Equal(t, "9.9.9.9 example.com https", res.String())
+
+	res = client.Get(ts.URL+"/proxy").
+		SetHeader("Forwarded", `for="[2001:db8::1]:4711";proto=https;host=api.example.com, for=10.1.1.1`).
+		Query().Send()
+	assert.Equal(t, "2001:db8::1 api.example.com https", res.String())
+
+	// values put by the client in front of the trusted hops are ignored
+	res = client.Get(ts.URL+"/proxy").
+		SetHeader("Forwarded", `for=6.6.6.6;host=evil.com;proto=http, for=9.9.9.9;host=good.com;proto=https, for=10.0.0.2`).
+		Query().Send()
+	assert.Equal(t, "9.9.9.9 good.com https", res.String())
+
+	res = client.Get(ts.URL+"/proxy").
+		SetHeader("X-Forwarded-For", "9.9.9.9").
+		SetHeader("X-Forwarded-Host", "evil.com, example.com").
+		SetHeader("X-Forwarded-Proto", "http, https").
+		Query().Send()
+	assert.Equal(t, "9.9.9.9 example.com https", res.String())
+}
+
+func Test_HTTP_RateLimit(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	var policy = &ratelimit.Policy{Rate: 0.5, Burst: 2, Scope: ratelimit.IP}
+
+	httpServerRouter.Method("GET").Route("/limit").Before(http.RateLimit[server.Conn](policy)).Handler(func(stream *http.Stream[server.Conn]) error {
+		return stream.Sender.String("ok")
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	for i := 0; i < 2; i++ {
+		var res = client.Get(ts.URL + "/limit").Query().Send()
+		assert.Equal(t, "ok", res.String())
+	}
+
+	var res = client.Get(ts.URL + "/limit").Query().Send()
+	assert.Equal(t, http2.StatusTooManyRequests, res.Code())
+	assert.Equal(t, "2", res.Response().Header.Get("Retry-After"))
+
+	// the least recently used key is forgotten past MaxKeys
+	var bounded = &ratelimit.Policy{Rate: 0.001, Burst: 1, Scope: ratelimit.IP, MaxKeys: 2}
+	var steps = []struct {
+		key   string
+		allow bool
+	}{{"a", true}, {"b", true}, {"a", false}, {"c", true}, {"b", true}, {"a", true}}
+	for _, step := range steps {
+		ok, _ := bounded.Allow(step.key)
+		assert.Equal(t, step.allow, ok, step.key)
+	}
+}
+
+func Test_HTTP_Logger(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	httpServerRouter.Method("GET").Route("/logger").Handler(func(stream *http.Stream[server.Conn]) error {
+		stream.Logger.Info("hello")
+		return stream.Sender.String("ok")
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	var buf bytes.Buffer
+	var logger = httpServer.Logger
+	httpServer.Logger = kitty2.NewLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+	httpServer.Ready()
+	defer func() {
+		httpServer.Logger = logger
+		httpServer.Ready()
+	}()
+
+	var res = client.Get(ts.URL + "/logger").Query().Send()
+	assert.Equal(t, "ok", res.String())
+	assert.Contains(t, buf.String(), "msg=hello")
+	assert.Contains(t, buf.String(), "method=GET path=/logger")
+}
+
+func Test_HTTP_RespondWithError(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	httpServerRouter.Method("GET").Route("/coded").Handler(func(stream *http.Stream[server.Conn]) error {
+		return stream.Sender.RespondWithError(0, errors.Invalid.WithCause(errors.New("id is empty")))
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	var res = client.Get(ts.URL + "/coded").Query().Send()
+	assert.Equal(t, http2.StatusBadRequest, res.Code())
+	assert.Equal(t, "invalid", res.String())
+}
+
+func Test_HTTP_ProblemDetails(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	type Login struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	httpServerRouter.Method("POST").Route("/problem").Handler(func(stream *http.Stream[server.Conn]) error {
+		var login Login
+		return stream.Json.Validate(&login)
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	httpServer.ProblemDetails = true
+	defer func() { httpServer.ProblemDetails = false }()
+
+	var res = client.Get(ts.URL + "/missing").Query().Send()
+	assert.Equal(t, http2.StatusNotFound, res.Code())
+	assert.Equal(t, "application/problem+json", res.Response().Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"route not found","instance":"/missing"}`, res.String())
+
+	res = client.Get(ts.URL + "/problem").Query().Send()
+	assert.Equal(t, http2.StatusMethodNotAllowed, res.Code())
+
+	res = client.Post(ts.URL + "/problem").Json(kitty2.M{}).Send()
+	assert.Equal(t, http2.StatusBadRequest, res.Code())
+	assert.Contains(t, res.String(), `"errors":[{"key":"name","type":"string","value":"","op":"required"`)
+}
+
+func Test_HTTP_Bind(t *testing.T) {
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	type Search struct {
+		ID    int       `param:"id"`
+		Tags  []string  `query:"tag"`
+		Page  int       `query:"page" validate:"gte:1"`
+		Since time.Time `query:"since"`
+		Token string    `header:"X-Token" validate:"required"`
+		Name  string    `json:"name" validate:"required"`
+	}
+
+	httpServerRouter.Method("POST").Route("/bind/:id").Handler(func(stream *http.Stream[server.Conn]) error {
+		var search, err = http.Bind[Search](stream)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, 7, search.ID)
+		assert.Equal(t, []string{"a", "b"}, search.Tags)
+		assert.Equal(t, 2, search.Page)
+		assert.Equal(t, 2024, search.Since.Year())
+		assert.Equal(t, "secret", search.Token)
+		assert.Equal(t, "kitty", search.Name)
+		return stream.Sender.String("hello Bind!")
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	httpServer.ProblemDetails = true
+	defer func() { httpServer.ProblemDetails = false }()
+
+	var res = client.Post(ts.URL+"/bind/7?tag=a&tag=b&page=2&since=2024-01-02T03:04:05Z").
+		SetHeader("X-Token", "secret").Json(kitty2.M{"name": "kitty"}).Send()
+	assert.Equal(t, "hello Bind!", res.String())
+
+	res = client.Post(ts.URL + "/bind/x?page=0").Json(kitty2.M{}).Send()
+	assert.Equal(t, http2.StatusBadRequest, res.Code())
+	assert.Contains(t, res.String(), `"key":"id","type":"int","value":"x","op":"type"`)
+
+	// problems are listed in field order
+	var problem http.Problem
+	assert.Nil(t, json.Unmarshal(res.Bytes(), &problem))
+	var keys []string
+	for i := 0; i < len(problem.Errors); i++ {
+		keys = append(keys, problem.Errors[i].Key+" "+problem.Errors[i].Op)
+	}
+	assert.Equal(t, []string{"id type", "Page gte", "Token required", "name required"}, keys)
+
+	type Page struct {
+		Page int `query:"page"`
+	}
+
+	httpServerRouter.Method("GET").Route("/page").Handler(func(stream *http.Stream[server.Conn]) error {
+		var _, err = http.Bind[Page](stream)
+		var num *strconv.NumError
+		assert.True(t, errors.As(err, &num), err)
+		assert.True(t, errors.Is(err, errors.Invalid), err)
+		return err
+	})
+
+	res = client.Get(ts.URL + "/page?page=x").Query().Send()
+	assert.Equal(t, http2.StatusBadRequest, res.Code())
+
+	// without problem details the handler answers on its own
+	httpServer.ProblemDetails = false
+	res = client.Post(ts.URL + "/bind/x").Json(kitty2.M{}).Send()
+	assert.Equal(t, http2.StatusOK, res.Code())
+}
+
+func Test_HTTP_ValidateRules(t *testing.T) {
+
+	type Item struct {
+		Price int `json:"price" validate:"gt:0"`
+	}
+
+	type Order struct {
+		Name   string            `json:"name" validate:"min:2,max:8"`
+		Code   string            `json:"code" validate:"len:3,regex:^[A-Z]+$"`
+		Slug   string            `json:"slug" validate:"regex:^[a-z]{2,4}$,required"`
+		Pair   string            `json:"pair" validate:"regex:^a\\,b$"`
+		Color  string            `json:"color" validate:"oneof:red green"`
+		Email  string            `json:"email" validate:"email"`
+		Site   string            `json:"site" validate:"url"`
+		ID     string            `json:"id" validate:"uuid"`
+		IP     string            `json:"ip" validate:"ip"`
+		Net    string            `json:"net" validate:"cidr"`
+		Day    string            `json:"day" validate:"datetime:2006-01-02 15:04"`
+		Tags   []string          `json:"tags" validate:"dive,min:2"`
+		Labels map[string]string `json:"labels" validate:"dive,oneof:a b"`
+		Items  []Item            `json:"items"`
+	}
+
+	var valid = `{"name":"kitty","code":"ABC","slug":"abc","pair":"a,b","color":"red","email":"a@b.io","site":"https://b.io/x",
+		"id":"123e4567-e89b-12d3-a456-426614174000","ip":"::1","net":"10.0.0.0/8","day":"2024-01-02 03:04",
+		"tags":["ab"],"labels":{"x":"a"},"items":[{"price":1}]}`
+
+	var bind = func(patch string) error {
+		var m map[string]any
+		assert.Nil(t, json.Unmarshal([]byte(valid), &m))
+		assert.Nil(t, json.Unmarshal([]byte(patch), &m))
+		var bts, _ = json.Marshal(m)
+		var order Order
+		return http.NewValidator[*Order]().From(bts).Bind(&order)
+	}
+
+	assert.Nil(t, bind(`{}`))
+
+	var cases = map[string]string{
+		`{"name":"k"}`:                   "name length >= 2 but got k",
+		`{"name":"kittykitty"}`:          "name length <= 8 but got kittykitty",
+		`{"code":"AB"}`:                  "code length = 3 but got AB",
+		`{"code":"abc"}`:                 "code must match ^[A-Z]+$ but got abc",
+		`{"slug":"abcdef"}`:              "slug must match ^[a-z]{2,4}$ but got abcdef",
+		`{"pair":"ab"}`:                  "pair must match ^a,b$ but got ab",
+		`{"color":"blue"}`:               "color must be one of red green but got blue",
+		`{"email":"kitty"}`:              "email must be a valid email but got kitty",
+		`{"site":"/x"}`:                  "site must be a valid url but got /x",
+		`{"id":"123"}`:                   "id must be a valid uuid but got 123",
+		`{"ip":"1.2.3"}`:                 "ip must be a valid ip but got 1.2.3",
+		`{"net":"10.0.0.0"}`:             "net must be a valid cidr but got 10.0.0.0",
+		`{"day":"2024-01-02"}`:           "day must be a datetime like 2006-01-02 15:04 but got 2024-01-02",
+		`{"tags":["ab","c"]}`:            "tags[1] length >= 2 but got c",
+		`{"labels":{"x":"c"}}`:           "labels[x] must be one of a b but got c",
+		`{"labels":{"z":"e","y":"d"}}`:   "labels[y] must be one of a b but got d",
+		`{"items":[{"price":0}]}`:        "price > 0 but got 0",
+		`{"email":"","site":"","id":""}`: "",
+	}
+
+	for patch, message := range cases {
+		var err = bind(patch)
+		if message == "" {
+			assert.Nil(t, err, patch)
+			continue
+		}
+		assert.NotNil(t, err, patch)
+		if err != nil {
+			assert.Equal(t, message, err.Error(), patch)
+		}
+	}
+
+	type Broken struct {
+		Name string `json:"name" validate:"regex:^[a-z$"`
+	}
+
+	// a bad pattern is an error, not a panic holding the tag lock
+	for i := 0; i < 2; i++ {
+		var broken Broken
+		assert.NotNil(t, http.NewValidator[*Broken]().From([]byte(`{"name":"a"}`)).Bind(&broken))
+	}
+	assert.Nil(t, bind(`{}`))
+}
+
+func Test_HTTP_ValidateAll(t *testing.T) {
+
+	type Item struct {
+		Price int `json:"price" validate:"gt:0"`
+	}
+
+	type Order struct {
+		Name  string   `json:"name,omitempty" validate:"required"`
+		Tags  []string `json:"tags" validate:"dive,min:2"`
+		Items []Item   `json:"items"`
+	}
+
+	var bts = []byte(`{"tags":["a"],"items":[{"price":1},{"price":0},{"price":-1}]}`)
+
+	var order Order
+	assert.NotNil(t, http.NewValidator[*Order]().From(bts).Bind(&order))
+
+	var err = http.NewValidator[*Order]().All().From(bts).Bind(&order)
+
+	var errs, ok = err.(http.ValidationErrors)
+	assert.True(t, ok, err)
+	assert.Equal(t, 4, len(errs))
+
+	var res, _ = json.Marshal(errs)
+	assert.JSONEq(t, `[
+		{"path":"name","op":"required","type":"string","value":"","message":"name is required but got ''"},
+		{"path":"tags[0]","op":"min","contract":"2","type":"string","value":"a","message":"tags[0] length >= 2 but got a"},
+		{"path":"items[1].price","op":"gt","contract":"0","type":"int","value":0,"message":"price > 0 but got 0"},
+		{"path":"items[2].price","op":"gt","contract":"0","type":"int","value":-1,"message":"price > 0 but got -1"}
+	]`, string(res))
+}
+
+func Test_HTTP_CustomRules(t *testing.T) {
+
+	http.RegisterRule("sku", func(ctx *http.RuleContext) bool {
+		return strings.HasPrefix(ctx.Value.String(), "SKU-")
+	})
+
+	type Payment struct {
+		SKU      string    `json:"sku" validate:"sku"`
+		Type     string    `json:"type"`
+		Card     string    `json:"card" validate:"required_if=Type:card"`
+		Password string    `json:"password"`
+		Confirm  string    `json:"confirm" validate:"eqfield=password"`
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end" validate:"gtfield=Start"`
+	}
+
+	var bind = func(body string) error {
+		var payment Payment
+		return http.NewValidator[*Payment]().From([]byte(body)).Bind(&payment)
+	}
+
+	var times = `"start":"2024-01-01T00:00:00Z","end":"2024-01-02T00:00:00Z"`
+
+	assert.Nil(t, bind(`{"sku":"SKU-1","type":"cash","password":"a","confirm":"a",`+times+`}`))
+	assert.Nil(t, bind(`{"sku":"SKU-1","type":"card","card":"4242","password":"a","confirm":"a",`+times+`}`))
+
+	assert.Equal(t, "sku must pass sku but got 1", bind(`{"sku":"1","password":"a","confirm":"a",`+times+`}`).Error())
+	assert.Equal(t, "card is required when Type:card but got ''", bind(`{"sku":"SKU-1","type":"card",`+times+`}`).Error())
+	assert.Equal(t, "confirm must equal password but got b", bind(`{"sku":"SKU-1","password":"a","confirm":"b",`+times+`}`).Error())
+	assert.Contains(t, bind(`{"sku":"SKU-1","start":"2024-01-02T00:00:00Z","end":"2024-01-01T00:00:00Z"}`).Error(), "end > Start")
+
+	// misspelled rules and fields fail instead of passing
+	type Typo struct {
+		Name string `json:"name" validate:"requird,emial"`
+	}
+	var typo Typo
+	assert.NotNil(t, http.NewValidator[*Typo]().From([]byte(`{"name":"a"}`)).Bind(&typo))
+
+	type Missing struct {
+		Card string `json:"card" validate:"required_if=Kind:x"`
+	}
+	var missing Missing
+	assert.NotNil(t, http.NewValidator[*Missing]().From([]byte(`{"card":"4242"}`)).Bind(&missing))
+
+	http.RegisterMessages("zh", map[string]string{"required_if": "{key} 在 {contract} 时必填"})
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	httpServerRouter.Method("POST").Route("/payment").Handler(func(stream *http.Stream[server.Conn]) error {
+		var payment Payment
+		return stream.Json.ValidateAll(&payment)
+	})
+
+	httpServer.SetRouter(httpServerRouter)
+
+	httpServer.ProblemDetails = true
+	defer func() { httpServer.ProblemDetails = false }()
+
+	var res = client.Post(ts.URL+"/payment").SetHeader("Accept-Language", "fr;q=0.9, zh-CN;q=0.8").
+		Json(kitty2.M{"sku": "SKU-1", "type": "card"}).Send()
+	assert.Equal(t, http2.StatusBadRequest, res.Code())
+	assert.Contains(t, res.String(), `"detail":"card 在 Type:card 时必填"`)
+
+	res = client.Post(ts.URL + "/payment").Json(kitty2.M{"sku": "SKU-1", "type": "card"}).Send()
+	assert.Contains(t, res.String(), `"detail":"card is required when Type:card but got ''"`)
+}
+
+type SchemaNode struct {
+	Name     string        `json:"name" validate:"required,min:1"`
+	Children []*SchemaNode `json:"children"`
+}
+
+func Test_HTTP_Schema(t *testing.T) {
+
+	type Address struct {
+		City string `json:"city" validate:"required"`
+	}
+
+	type User struct {
+		Name    string            `json:"name,omitempty" validate:"required,max:8"`
+		Age     int               `json:"age" validate:"gte:0,lte:150,default:18"`
+		Role    string            `json:"role" validate:"oneof:admin user"`
+		Tags    []string          `json:"tags" validate:"nonempty,dive,min:2"`
+		Meta    map[string]int    `json:"meta"`
+		Home    Address           `json:"home"`
+		Work    *Address          `json:"work"`
+		Tree    SchemaNode        `json:"tree"`
+		Created time.Time         `json:"created"`
+		Secret  string            `json:"-"`
+		Extra   map[string]string `json:"extra" validate:"nonempty"`
+	}
+
+	var res, err = json.Marshal(http.NewSchema(&User{}))
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"$schema":"https://json-schema.org/draft/2020-12/schema",
+		"type":"object",
+		"properties":{
+			"name":{"type":"string","maxLength":8},
+			"age":{"type":"integer","minimum":0,"maximum":150,"default":18},
+			"role":{"type":"string","enum":["admin","user"]},
+			"tags":{"type":"array","items":{"type":"string","minLength":2},"minItems":1},
+			"meta":{"type":"object","additionalProperties":{"type":"integer"}},
+			"home":{"$ref":"#/$defs/Address"},
+			"work":{"$ref":"#/$defs/Address"},
+			"tree":{"$ref":"#/$defs/SchemaNode"},
+			"created":{"type":"string","format":"date-time"},
+			"extra":{"type":"object","additionalProperties":{"type":"string"},"minProperties":1}
+		},
+		"required":["name"],
+		"$defs":{
+			"Address":{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]},
+			"SchemaNode":{"type":"object","properties":{
+				"name":{"type":"string","minLength":1},
+				"children":{"type":"array","items":{"$ref":"#/$defs/SchemaNode"}}
+			},"required":["name"]}
+		}
+	}`, string(res))
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	httpServerRouter.Method("GET").Route("/schema").Handler(http.ServeSchema[server.Conn](&User{}))
+
+	httpServer.SetRouter(httpServerRouter)
+
+	var resp = client.Get(ts.URL + "/schema").Query().Send()
+	assert.JSONEq(t, string(res), resp.String())
+}
+
+func Test_HTTP_OpenAPI(t *testing.T) {
+
+	type User struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	type UserParams struct {
+		ID    int    `param:"id" validate:"gte:1"`
+		Token string `header:"X-Token" validate:"required"`
+		Page  int    `query:"page"`
+	}
+
+	var httpServerRouter = &router.Router[*http.Stream[server.Conn], any]{}
+
+	var f = func(stream *http.Stream[server.Conn]) error { return nil }
+
+	httpServerRouter.Group("/user").Desc("user").Handler(func(handler *router.Handler[*http.Stream[server.Conn], any]) {
+		handler.Get("/:id").Desc("get a user").Doc(&router.Doc{Params: UserParams{}, Response: User{}}).Handler(f)
+		handler.Post("/:id/files/*").Doc(&router.Doc{Summary: "upload", Request: User{}, Deprecated: true}).Handler(f)
+	})
+
+	var info = openapi.Info{Title: "kitty", Version: "1.0.0"}
+
+	httpServerRouter.Method("GET").Route("/docs/openapi.json").Handler(openapi.Handler(httpServerRouter, info))
+
+	var staticRouter = &server.StaticRouter{}
+	staticRouter.SetStaticPath("/docs", "", openapi.UI())
+
+	httpServer.SetRouter(httpServerRouter)
+	httpServer.SetStaticRouter(staticRouter)
+	defer httpServer.SetStaticRouter(nil)
+
+	var res = client.Get(ts.URL + "/docs/openapi.json").Query().Send()
+	assert.JSONEq(t, `{
+		"openapi":"3.1.0",
+		"info":{"title":"kitty","version":"1.0.0"},
+		"tags":[{"name":"user"}],
+		"paths":{
+			"/docs/openapi.json":{"get":{"responses":{"200":{"description":"OK"}}}},
+			"/user/{id}":{"get":{
+				"tags":["user"],"summary":"get a user",
+				"parameters":[
+					{"name":"id","in":"path","required":true,"schema":{"type":"integer","minimum":1}},
+					{"name":"X-Token","in":"header","required":true,"schema":{"type":"string"}},
+					{"name":"page","in":"query","schema":{"type":"integer"}}
+				],
+				"responses":{"200":{"description":"OK","content":{"application/json":{"schema":{"$ref":"#/components/schemas/User"}}}}}
+			}},
+			"/user/{id}/files/{path}":{"post":{
+				"tags":["user"],"summary":"upload","deprecated":true,
+				"parameters":[
+					{"name":"id","in":"path","required":true,"schema":{"type":"string"}},
+					{"name":"path","in":"path","required":true,"schema":{"type":"string"}}
+				],
+				"requestBody":{"required":true,"content":{"application/json":{"schema":{"$ref":"#/components/schemas/User"}}}},
+				"responses":{"200":{"description":"OK"}}
+			}}
+		},
+		"components":{"schemas":{"User":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}}}
+	}`, res.String())
+
+	// the document follows the routes
+	httpServerRouter.Remove("/user/:id/files/*")
+	httpServerRouter.Method("GET").Route("/ping").Handler(f)
+	res = client.Get(ts.URL + "/docs/openapi.json").Query().Send()
+	assert.Contains(t, res.String(), `"/ping":`)
+	assert.NotContains(t, res.String(), `/files/`)
+
+	res = client.Get(ts.URL + "/docs/index.html").Query().Send()
+	assert.Contains(t, res.String(), "SwaggerUIBundle")
+	assert.Contains(t, res.String(), `<script src="https://cdn.jsdelivr.net/gh/swagger-api/swagger-ui@v5.17.14/dist/swagger-ui-bundle.js" integrity="sha384-`)
+	assert.Contains(t, res.String(), `SwaggerUIBundle({url: "openapi.json",`)
+	assert.NotContains(t, res.String(), "location.search")
+
+	staticRouter.SetStaticPath("/mirror", "", openapi.UIWith(openapi.Assets{
+		CSS: "/assets/swagger-ui.css", JS: "/assets/swagger-ui-bundle.js", JSIntegrity: "sha384-abc", Spec: "/v1/openapi.json",
+	}))
+	res = client.Get(ts.URL + "/mirror/index.html").Query().Send()
+	assert.Contains(t, res.String(), `<link rel="stylesheet" href="/assets/swagger-ui.css" crossorigin="anonymous">`)
+	assert.Contains(t, res.String(), `<script src="/assets/swagger-ui-bundle.js" integrity="sha384-abc" crossorigin="anonymous">`)
+	assert.Contains(t, res.String(), `SwaggerUIBundle({url: "/v1/openapi.json",`)
+}
+
+func Test_HTTP_Hosts(t *testing.T) {
+
+	var newRouter = func(name string) *router.Router[*http.Stream[server.Conn], any] {
+		var r = &router.Router[*http.Stream[server.Conn], any]{}
+		r.Method("GET").Route("/whoami").Handler(func(stream *http.Stream[server.Conn]) error {
+			return stream.Sender.String(name + " " + stream.Params.Get("tenant"))
+		})
+		return r
+	}
+
+	var staticRouter = &server.StaticRouter{}
+	staticRouter.SetStaticPath("/", "", http2.Dir("../../example/http/public"))
+
+	var hostServer = kitty.NewHttpServer[any]("127.0.0.1:12347")
+	hostServer.SetRouter(newRouter("default"))
+	hostServer.AddHost(&server.Host[any]{Pattern: "api.example.com", Version: "2", Router: newRouter("v2")})
+	hostServer.AddHost(&server.Host[any]{Pattern: "api.example.com", Header: "X-Api-Version", Value: "3", Router: newRouter("v3")})
+	hostServer.AddHost(&server.Host[any]{Pattern: "api.example.com", Router: newRouter("api")})
+	hostServer.AddHost(&server.Host[any]{Pattern: ":tenant.example.com", Router: newRouter("tenant")})
+	hostServer.AddHost(&server.Host[any]{Pattern: "*.static.example.com", StaticRouter: staticRouter})
+
+	var serve = func(host string, headers ...string) *httptest.ResponseRecorder {
+		var req = httptest.NewRequest("GET", "http://"+host+"/whoami", nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		var w = httptest.NewRecorder()
+		hostServer.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, "api ", serve("api.example.com:8080").Body.String())
+	assert.Equal(t, "v2 ", serve("api.example.com", "Accept", "application/vnd.kitty.v2+json").Body.String())
+	assert.Equal(t, "v2 ", serve("api.example.com", "Accept", "application/json; version=2").Body.String())
+	assert.Equal(t, "v3 ", serve("api.example.com", "X-Api-Version", "3").Body.String())
+	assert.Equal(t, "tenant acme", serve("ACME.example.com").Body.String())
+	assert.Equal(t, "default ", serve("a.b.example.com").Body.String())
+	assert.Equal(t, "default ", serve("localhost").Body.String())
+
+	var req = httptest.NewRequest("GET", "http://cdn.eu.static.example.com/test.txt", nil)
+	var w = httptest.NewRecorder()
+	hostServer.ServeHTTP(w, req)
+	assert.Equal(t, "hello static!", w.Body.String())
+
+	// without a default router an unknown host is not found
+	var failed error
+	var opened, closed int
+	hostServer.SetRouter(nil)
+	hostServer.OnOpen = func(stream *http.Stream[server.Conn]) { opened++ }
+	hostServer.OnClose = func(stream *http.Stream[server.Conn]) { closed++ }
+	hostServer.OnError = func(stream *http.Stream[server.Conn], err error) { failed = err }
+	w = serve("localhost")
+	assert.Equal(t, http2.StatusNotFound, w.Code)
+	assert.True(t, errors.Is(failed, errors.RouteNotFount))
+	assert.Equal(t, 1, opened)
+	assert.Equal(t, 1, closed)
+}
+
+func Test_HTTP_HeadOptionsRedirect(t *testing.T) {
+
+	var r = &router.Router[*http.Stream[server.Conn], any]{}
+	r.Method("GET").Route("/users").Handler(func(stream *http.Stream[server.Conn]) error {
+		return stream.Sender.String("users")
+	})
+	r.Method("POST").Route("/items/").Handler(func(stream *http.Stream[server.Conn]) error {
+		return stream.Sender.String("items")
+	})
+	r.Method("GET").Route("/:id").Handler(func(stream *http.Stream[server.Conn]) error {
+		return stream.Sender.String(stream.Params.Get("id"))
+	})
+
+	var normalizeServer = kitty.NewHttpServer[any]("127.0.0.1:12348")
+	normalizeServer.SetRouter(r)
+
+	// every stream that opens closes, answered by a handler or not
+	var opened, closed int
+	normalizeServer.OnOpen = func(stream *http.Stream[server.Conn]) { opened++ }
+	normalizeServer.OnClose = func(stream *http.Stream[server.Conn]) { closed++ }
+
+	var serve = func(method string, target string) *httptest.ResponseRecorder {
+		var w = httptest.NewRecorder()
+		normalizeServer.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	httpServer.SetRouter(r)
+	var res = client.Head(ts.URL + "/users").Query().Send()
+	assert.Equal(t, http2.StatusOK, res.Code())
+	assert.Equal(t, "", res.String())
+	assert.Equal(t, int64(5), res.Response().ContentLength)
+
+	var w = serve("OPTIONS", "/users")
+	assert.Equal(t, http2.StatusNoContent, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+	assert.Equal(t, 1, opened)
+	assert.Equal(t, 1, closed)
+
+	w = serve("DELETE", "/users")
+	assert.Equal(t, http2.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+
+	// without redirects both forms reach the route
+	assert.Equal(t, "users", serve("GET", "/users/").Body.String())
+
+	normalizeServer.RedirectTrailingSlash = true
+	normalizeServer.RedirectCleanPath = true
+
+	w = serve("GET", "/users/?page=2")
+	assert.Equal(t, http2.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/users?page=2", w.Header().Get("Location"))
+
+	w = serve("POST", "/items")
+	assert.Equal(t, http2.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/items/", w.Header().Get("Location"))
+
+	w = serve("GET", "/a//b/./../../users")
+	assert.Equal(t, http2.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/users", w.Header().Get("Location"))
+
+	opened, closed = 0, 0
+	serve("GET", "/users/")
+	serve("GET", "/a//b")
+	assert.Equal(t, 2, opened)
+	assert.Equal(t, 2, closed)
+
+	assert.Equal(t, "users", serve("GET", "/users").Body.String())
+
+	// empty segments match /:id, the location must stay on this host
+	w = serve("GET", "//evil.com/")
+	assert.Equal(t, http2.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/evil.com/", w.Header().Get("Location"))
+
+	normalizeServer.RedirectCleanPath = false
+
+	w = serve("GET", "//evil.com/")
+	assert.Equal(t, "/evil.com", w.Header().Get("Location"))
+
+	w = serve("GET", "/%5Cevil.com/")
+	assert.Equal(t, "/evil.com", w.Header().Get("Location"))
+}
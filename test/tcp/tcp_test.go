@@ -11,9 +11,11 @@
 package tcp
 
 import (
+	"context"
 	"fmt"
 	json "github.com/lemonyxk/kitty/json"
 	"math/rand"
+	"net"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -21,10 +23,13 @@ import (
 	"time"
 
 	"github.com/lemonyxk/kitty"
+	"github.com/lemonyxk/kitty/errors"
 	hello "github.com/lemonyxk/kitty/example/protobuf"
 	kitty2 "github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/router"
 	"github.com/lemonyxk/kitty/socket"
+	"github.com/lemonyxk/kitty/socket/auth"
+	"github.com/lemonyxk/kitty/socket/ratelimit"
 	"github.com/lemonyxk/kitty/socket/tcp/client"
 	"github.com/lemonyxk/kitty/socket/tcp/server"
 	"github.com/stretchr/testify/assert"
@@ -441,6 +446,227 @@ func Test_TCP_Multi_Client(t *testing.T) {
 	assert.True(t, count == 100, fmt.Sprintf("count:%d", count))
 }
 
+func Test_TCP_HandlerTimeout(t *testing.T) {
+
+	var done = make(chan error, 1)
+	var late = make(chan error, 1)
+	var reported int32
+
+	var onError = tcpServer.OnError
+	tcpServer.OnError = func(stream *socket.Stream[server.Conn], err error) {
+		atomic.AddInt32(&reported, 1)
+	}
+	defer func() { tcpServer.OnError = onError }()
+
+	tcpServerRouter.Route("/timeout").Timeout(time.Millisecond * 50).Handler(func(stream *socket.Stream[server.Conn]) error {
+		<-stream.Context.Done()
+		done <- stream.Context.Err()
+		// answered by the timeout already, dropped
+		late <- stream.Respond([]byte("late"))
+		return errors.New("late")
+	})
+
+	var asyncClient = socket.NewAsyncClient[client.Conn, any](tcpClient)
+
+	var _, err = asyncClient.Emit("/timeout", nil)
+	replyError, ok := err.(*socket.ReplyError)
+	assert.True(t, ok, err)
+	assert.Equal(t, uint32(408), replyError.Code)
+	assert.Equal(t, context.DeadlineExceeded, <-done)
+	assert.True(t, errors.Is(<-late, errors.Timeout))
+
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&reported))
+
+	// json requests get the json error body
+	_, err = asyncClient.JsonEmit("/timeout", nil)
+	replyError, ok = err.(*socket.ReplyError)
+	assert.True(t, ok, err)
+	assert.Equal(t, uint32(408), replyError.Code)
+	assert.Equal(t, errors.MessageOf(errors.Timeout), replyError.Message)
+	<-done
+	<-late
+}
+
+func Test_TCP_RateLimitReply(t *testing.T) {
+
+	var policy = &ratelimit.Policy{Rate: 0.001, Burst: 1, Scope: ratelimit.Conn, Action: ratelimit.Reply}
+
+	tcpServerRouter.Route("/limited").Before(socket.RateLimit[server.Conn](policy)).Handler(func(stream *socket.Stream[server.Conn]) error {
+		return stream.JsonEmit(stream.Event(), "ok")
+	})
+
+	var asyncClient = socket.NewAsyncClient[client.Conn, any](tcpClient)
+
+	var _, err = asyncClient.JsonEmit("/limited", nil)
+	assert.Nil(t, err)
+
+	_, err = asyncClient.JsonEmit("/limited", nil)
+	replyError, ok := err.(*socket.ReplyError)
+	assert.True(t, ok, err)
+	assert.Equal(t, errors.RateLimited.Code(), replyError.Code)
+	assert.Equal(t, errors.MessageOf(errors.RateLimited), replyError.Message)
+}
+
+func Test_TCP_ReplyError(t *testing.T) {
+
+	tcpServer.ReplyError = true
+	defer func() { tcpServer.ReplyError = false }()
+
+	tcpServerRouter.Route("/fail").Handler(func(stream *socket.Stream[server.Conn]) error {
+		return errors.New("boom")
+	})
+
+	var asyncClient = socket.NewAsyncClient[client.Conn, any](tcpClient)
+
+	var _, err = asyncClient.JsonEmit("/fail", nil)
+	replyError, ok := err.(*socket.ReplyError)
+	assert.True(t, ok, err)
+	assert.Equal(t, uint32(500), replyError.Code)
+	assert.Equal(t, "boom", replyError.Message)
+
+	_, err = asyncClient.Emit("/missing", nil)
+	replyError, ok = err.(*socket.ReplyError)
+	assert.True(t, ok, err)
+	assert.Equal(t, uint32(404), replyError.Code)
+	assert.Equal(t, "route not found", replyError.Message)
+	assert.True(t, errors.Is(err, errors.RouteNotFount))
+}
+
+func Test_TCP_ProxyProtocol(t *testing.T) {
+
+	var proxyServer = kitty.NewTcpServer[any]("127.0.0.1:8668")
+	proxyServer.ProxyProtocol = true
+	proxyServer.ProxyProtocolTrusted = []string{"127.0.0.1/32"}
+
+	var ips = make(chan string, 2)
+	proxyServer.OnOpen = func(conn server.Conn) {
+		ips <- conn.ClientIP()
+	}
+	proxyServer.OnClose = func(conn server.Conn) {}
+
+	var ready = make(chan bool)
+	proxyServer.OnSuccess = func() {
+		ready <- true
+	}
+
+	go proxyServer.Start()
+
+	<-ready
+
+	defer func() { _ = proxyServer.Shutdown() }()
+
+	v1, err := net.Dial("tcp", "127.0.0.1:8668")
+	assert.True(t, err == nil, err)
+	_, err = v1.Write([]byte("PROXY TCP4 1.2.3.4 10.0.0.1 4321 8668\r\n"))
+	assert.True(t, err == nil, err)
+	assert.Equal(t, "1.2.3.4", <-ips)
+	_ = v1.Close()
+
+	v2, err := net.Dial("tcp", "127.0.0.1:8668")
+	assert.True(t, err == nil, err)
+	var header = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A, 0x21, 0x11, 0x00, 0x0C}
+	header = append(header, 5, 6, 7, 8, 10, 0, 0, 1, 0x10, 0xE1, 0x21, 0xDC)
+	_, err = v2.Write(header)
+	assert.True(t, err == nil, err)
+	assert.Equal(t, "5.6.7.8", <-ips)
+	_ = v2.Close()
+
+	// nobody is trusted by default, so the list is required
+	var open = kitty.NewTcpServer[any]("127.0.0.1:8670")
+	open.ProxyProtocol = true
+	assert.Panics(t, open.Ready)
+}
+
+func Test_TCP_MaxConnections(t *testing.T) {
+
+	var limitServer = kitty.NewTcpServer[any]("127.0.0.1:8669")
+	limitServer.MaxConnections = 1
+
+	var opened = make(chan bool, 2)
+	limitServer.OnOpen = func(conn server.Conn) {
+		opened <- true
+	}
+	limitServer.OnClose = func(conn server.Conn) {}
+
+	var rejected = make(chan error, 1)
+	limitServer.OnReject = func(addr net.Addr, err error) {
+		rejected <- err
+	}
+
+	var ready = make(chan bool)
+	limitServer.OnSuccess = func() {
+		ready <- true
+	}
+
+	go limitServer.Start()
+
+	<-ready
+
+	defer func() { _ = limitServer.Shutdown() }()
+
+	first, err := net.Dial("tcp", "127.0.0.1:8669")
+	assert.True(t, err == nil, err)
+	<-opened
+
+	second, err := net.Dial("tcp", "127.0.0.1:8669")
+	assert.True(t, err == nil, err)
+	assert.True(t, errors.Is(<-rejected, errors.TooManyConnections))
+
+	// the server closed it without a word
+	_, err = second.Read(make([]byte, 1))
+	assert.True(t, err != nil)
+
+	_ = first.Close()
+	time.Sleep(time.Millisecond * 100)
+
+	third, err := net.Dial("tcp", "127.0.0.1:8669")
+	assert.True(t, err == nil, err)
+	<-opened
+	_ = third.Close()
+}
+
+func Test_TCP_Authorize(t *testing.T) {
+
+	tcpServer.ReplyError = true
+	defer func() { tcpServer.ReplyError = false }()
+
+	var conns = auth.NewConns()
+
+	tcpServerRouter.Route("/auth/login").Handler(func(stream *socket.Stream[server.Conn]) error {
+		conns.Set(stream.Conn().FD(), &auth.User{ID: "1", Permissions: []string{"read"}})
+		return stream.Emit(stream.Event(), nil)
+	})
+
+	var authorize = socket.Authorize(func(stream *socket.Stream[server.Conn]) auth.Identity {
+		return conns.Get(stream.Conn().FD())
+	})
+
+	tcpServerRouter.Group("/auth").Before(authorize).Handler(func(handler *router.Handler[*socket.Stream[server.Conn], any]) {
+		handler.Route("/read").Data(&auth.Rule{Permissions: []string{"read"}}).Handler(func(stream *socket.Stream[server.Conn]) error {
+			return stream.Emit(stream.Event(), []byte("read"))
+		})
+		handler.Route("/write").Data(&auth.Rule{Permissions: []string{"write"}}).Handler(func(stream *socket.Stream[server.Conn]) error {
+			return stream.Emit(stream.Event(), []byte("write"))
+		})
+	})
+
+	var asyncClient = socket.NewAsyncClient[client.Conn, any](tcpClient)
+
+	var _, err = asyncClient.Emit("/auth/read", nil)
+	assert.True(t, errors.Is(err, errors.Unauthorized), err)
+
+	_, err = asyncClient.Emit("/auth/login", nil)
+	assert.Nil(t, err)
+
+	stream, err := asyncClient.Emit("/auth/read", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "read", string(stream.Data()))
+
+	_, err = asyncClient.Emit("/auth/write", nil)
+	assert.True(t, errors.Is(err, errors.Forbidden), err)
+}
+
 func Test_TCP_Shutdown(t *testing.T) {
 	shutdown()
 }
@@ -24,6 +24,7 @@ import (
 	hello "github.com/lemonyxk/kitty/example/protobuf"
 	kitty2 "github.com/lemonyxk/kitty/kitty"
 	"github.com/lemonyxk/kitty/router"
+	"github.com/lemonyxk/kitty/socket/asyncapi"
 	"github.com/lemonyxk/kitty/socket/websocket/client"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/proto"
@@ -523,6 +524,64 @@ func Test_WS_Multi_Client(t *testing.T) {
 	assert.True(t, count == 100, fmt.Sprintf("count:%d", count))
 }
 
+func Test_WS_AsyncAPI(t *testing.T) {
+
+	type Login struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	type Token struct {
+		Token string `json:"token"`
+	}
+
+	var r = &router.Router[*socket.Stream[server.Conn], any]{}
+
+	var f = func(stream *socket.Stream[server.Conn]) error { return nil }
+
+	r.Group("/user").Desc("user").Handler(func(handler *router.Handler[*socket.Stream[server.Conn], any]) {
+		handler.Route("/login").Desc("login").Doc(&router.Doc{Request: Login{}, Response: Token{}}).Handler(f)
+		handler.Route("/logout").Doc(&router.Doc{Deprecated: true}).Handler(f)
+		handler.Route("/:id<int>/kick").Handler(f)
+	})
+
+	var doc = asyncapi.New(r, asyncapi.Info{Title: "chat", Version: "1.0.0"}, &asyncapi.Server{Host: addr, Protocol: "ws"})
+
+	var bts, err = json.Marshal(doc)
+	assert.Nil(t, err)
+
+	var res = string(bts)
+	assert.Contains(t, res, `"asyncapi":"3.0.0"`)
+	assert.Contains(t, res, `"servers":{"default":{"host":"127.0.0.1:8669","protocol":"ws"}}`)
+	assert.Contains(t, res, `"user.login":{"address":"/user/login","messages":{`)
+	assert.Contains(t, res, `"reply":{"channel":{"$ref":"#/channels/user.login"},"messages":[{"$ref":"#/channels/user.login/messages/reply"}]}`)
+	assert.Contains(t, res, `"Login":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}`)
+
+	assert.Equal(t, "/user/{id}/kick", doc.Channels["user.id.kick"].Address)
+	assert.Equal(t, "int", doc.Channels["user.id.kick"].Parameters["id"].Description)
+	// where handlers live is not published
+	assert.NotContains(t, res, ".go:")
+
+	assert.Nil(t, doc.Operations["user.logout"].Reply)
+	assert.True(t, doc.Operations["user.logout"].Deprecated)
+	assert.Equal(t, "login", doc.Operations["user.login"].Summary)
+
+	var markdown = doc.Markdown()
+	assert.Contains(t, markdown, "# chat 1.0.0")
+	assert.Contains(t, markdown, "Transport: ws 127.0.0.1:8669")
+	assert.Contains(t, markdown, "## /user/login\n\nlogin\n\nTags: user")
+	assert.Contains(t, markdown, "Reply:\n\n```json\n{\n  \"type\": \"object\"")
+
+	var html = doc.HTML()
+	assert.Contains(t, html, `<h2 class="deprecated">/user/logout</h2>`)
+
+	// /user.login and /user/login both read user.login
+	r.Route("/user.login").Handler(f)
+	doc = asyncapi.New(r, asyncapi.Info{Title: "chat", Version: "1.0.0"}, nil)
+	assert.Equal(t, "/user.login", doc.Channels["user.login"].Address)
+	assert.Equal(t, "/user/login", doc.Channels["user.login_2"].Address)
+	assert.Equal(t, "#/channels/user.login_2", doc.Operations["user.login_2"].Channel.Ref)
+}
+
 func Test_WS_Shutdown(t *testing.T) {
 	shutdown()
 }